@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ApiKeyAuthenticator maps the API key of the header, or of the query param when the header is missing,
+// to the identity of its consumer
+type ApiKeyAuthenticator struct {
+	realm      string
+	header     string
+	queryParam string
+	consumers  map[string]string
+}
+
+// CreateApiKeyAuthenticator reads the keys from the header only when queryParam is empty
+func CreateApiKeyAuthenticator(realm string, header string, queryParam string, consumersByKey map[string]string) *ApiKeyAuthenticator {
+	return &ApiKeyAuthenticator{
+		realm:      realm,
+		header:     header,
+		queryParam: queryParam,
+		consumers:  maps.Clone(consumersByKey),
+	}
+}
+
+func (a *ApiKeyAuthenticator) Authenticate(r *http.Request) (*Consumer, error) {
+	key := r.Header.Get(a.header)
+	if key == "" && a.queryParam != "" {
+		key = r.URL.Query().Get(a.queryParam)
+	}
+
+	consumer, ok := a.consumers[key]
+	if key == "" || !ok {
+		return nil, UnauthorizedErr
+	}
+
+	return &Consumer{Id: consumer}, nil
+}
+
+// StripCredential removes the key from both the header and the query param whichever one it was read from,
+// the other query params are kept as they were sent
+func (a *ApiKeyAuthenticator) StripCredential(r *http.Request) {
+	r.Header.Del(a.header)
+
+	if a.queryParam == "" || r.URL.RawQuery == "" {
+		return
+	}
+
+	r.URL.RawQuery = removeRawQueryParam(r.URL.RawQuery, a.queryParam)
+}
+
+// removeRawQueryParam removes the pairs of the param without encoding the query again
+func removeRawQueryParam(rawQuery string, name string) string {
+	pairs := strings.Split(rawQuery, "&")
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescapedKey, err := url.QueryUnescape(key); err == nil {
+			key = unescapedKey
+		}
+
+		if key != name {
+			kept = append(kept, pair)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func (a *ApiKeyAuthenticator) Challenge() string {
+	return fmt.Sprintf("ApiKey realm=%q", a.realm)
+}
@@ -2,10 +2,10 @@ package core
 
 import (
 	"context"
-	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 )
 
 type Application interface {
@@ -14,25 +14,26 @@ type Application interface {
 	Handler(w http.ResponseWriter, r *http.Request)
 }
 
-func CreateApplicationHandler(sb *ServiceBalancer, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+// UpstreamHandler forwards a request upstream, implemented by ServiceBalancer and TrafficSplit
+type UpstreamHandler interface {
+	HandleRequest(ctx context.Context, req *http.Request) (*http.Response, error)
+}
+
+func CreateApplicationHandler(upstream UpstreamHandler, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return CreateApplicationHandlerWithHeaderRules(upstream, DefaultResponseHeaderRules(), logger)
+}
+
+// CreateApplicationHandlerWithHeaderRules applies the response header rules to the upstream response before writing it
+func CreateApplicationHandlerWithHeaderRules(upstream UpstreamHandler, responseRules []*HeaderRule, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		resp, err := sb.HandleRequest(ctx, r)
+		resp, err := upstream.HandleRequest(ctx, r)
 		if err != nil {
-			if errors.Is(err, ServiceUnavailableErr) {
-				w.WriteHeader(http.StatusServiceUnavailable)
-			} else if errors.Is(err, BadGatewayErr) {
-				w.WriteHeader(http.StatusBadGateway)
-			} else if errors.Is(err, GatewayTimeoutErr) {
-				w.WriteHeader(http.StatusGatewayTimeout)
-			} else {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-
-			_, err := w.Write([]byte(err.Error()))
+			logger.Log(ctx, slog.LevelWarn, "an error occurred while calling application service", slog.Any("error", err), slog.String("request_id", RequestIdFromContext(ctx)))
 
+			err := WriteError(w, r, err)
 			if err != nil {
-				logger.Log(ctx, slog.LevelError, "an error occurred while calling application service", slog.Any("error", err))
+				logger.Log(ctx, slog.LevelError, "cannot write error to client", slog.Any("error", err))
 			}
 
 			return
@@ -45,7 +46,9 @@ func CreateApplicationHandler(sb *ServiceBalancer, logger *slog.Logger) func(w h
 			}
 		}(resp.Body)
 
-		writeCookiesToResponse(ctx, w, resp, logger)
+		rewriteUpstreamLocations(resp, r)
+		writeCookiesToResponse(ctx, w, r, resp, logger)
+		ApplyHeaderRules(resp.Header, r, responseRules)
 		writeHeadersToResponse(ctx, w, resp, logger)
 
 		w.WriteHeader(resp.StatusCode)
@@ -56,22 +59,22 @@ func CreateApplicationHandler(sb *ServiceBalancer, logger *slog.Logger) func(w h
 	}
 }
 
-func writeCookiesToResponse(ctx context.Context, w http.ResponseWriter, resp *http.Response, logger *slog.Logger) {
+func writeCookiesToResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, resp *http.Response, logger *slog.Logger) {
 	for _, cookie := range resp.Cookies() {
+		rewriteUpstreamCookie(cookie, resp.Request, r)
 		http.SetCookie(w, cookie)
 		logger.Log(ctx, slog.LevelDebug, "writing cookie to the response", slog.String("cookie_name", cookie.Name))
 	}
 }
 
 func writeHeadersToResponse(ctx context.Context, w http.ResponseWriter, resp *http.Response, logger *slog.Logger) {
-	for headerKey := range resp.Header {
-		if headerKey == "Server" || headerKey == "X-Powered-By" || headerKey == "X-Aspnet-Version" || headerKey == "X-Aspnetmvc-Version" {
-			logger.Log(ctx, slog.LevelDebug, "skipping header", slog.String("header_key", headerKey))
+	for headerKey, headerValues := range resp.Header {
+		if headerKey == "Set-Cookie" {
 			continue
 		}
 
-		logger.Log(ctx, slog.LevelDebug, "writing header to the response", slog.String("header_key", headerKey), slog.String("header_value", headerKey))
-		w.Header().Set(headerKey, resp.Header.Get(headerKey))
+		logger.Log(ctx, slog.LevelDebug, "writing header to the response", slog.String("header_key", headerKey))
+		w.Header()[headerKey] = slices.Clone(headerValues)
 	}
 }
 
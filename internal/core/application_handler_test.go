@@ -60,7 +60,7 @@ func TestApplicationHandler(t *testing.T) {
 		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
 	})
 
-	t.Run("should return 502 when no upstream is available", func(t *testing.T) {
+	t.Run("should return 503 when no upstream is available", func(t *testing.T) {
 		t.Parallel()
 
 		// arrange
@@ -74,7 +74,7 @@ func TestApplicationHandler(t *testing.T) {
 		handler(response, request)
 
 		// assert
-		assert.Equal(t, http.StatusBadGateway, response.Code)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
 	})
 }
 
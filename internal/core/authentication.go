@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+var UnauthorizedErr = errors.New("missing or invalid credentials")
+
+// Consumer is the authenticated identity of a request
+type Consumer struct {
+	Id string
+	// Headers are forwarded upstream along with the identity, such as the claims of a token
+	Headers http.Header
+}
+
+// Authenticator resolves the consumer identity from the credential of the request
+type Authenticator interface {
+	// Authenticate returns UnauthorizedErr when the request has no valid credential
+	Authenticate(r *http.Request) (*Consumer, error)
+	// StripCredential removes the credential, and the headers the authenticator forwards, sent by the client
+	StripCredential(r *http.Request)
+	// Challenge returns the value of the WWW-Authenticate header sent with a 401
+	Challenge() string
+}
+
+// Authentication accepts the requests authenticated by any of its authenticators, tried in order,
+// and forwards the identity of the consumer in the consumer header
+type Authentication struct {
+	consumerHeader string
+	authenticators []Authenticator
+}
+
+func CreateAuthentication(consumerHeader string, authenticators ...Authenticator) (*Authentication, error) {
+	if consumerHeader == "" {
+		return nil, errors.New("authentication consumer header cannot be empty")
+	}
+
+	if len(authenticators) == 0 {
+		return nil, errors.New("authentication requires at least one authenticator")
+	}
+
+	return &Authentication{
+		consumerHeader: consumerHeader,
+		authenticators: slices.Clone(authenticators),
+	}, nil
+}
+
+// Middleware forwards a copy of the authenticated requests so the previous middlewares still see the original one.
+// The values sent by the client of the consumer header and of the headers forwarded by any of the authenticators
+// are never trusted, they are removed along with the credentials of every authenticator
+func (a *Authentication) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range a.authenticators {
+				consumer, err := authenticator.Authenticate(r)
+				if err != nil {
+					continue
+				}
+
+				next.ServeHTTP(w, a.authenticatedRequest(r, consumer))
+				return
+			}
+
+			for _, authenticator := range a.authenticators {
+				w.Header().Add("WWW-Authenticate", authenticator.Challenge())
+			}
+
+			_ = WriteError(w, r, UnauthorizedErr)
+		})
+	}
+}
+
+func (a *Authentication) authenticatedRequest(r *http.Request, consumer *Consumer) *http.Request {
+	authenticatedRequest := r.Clone(WithConsumer(r.Context(), consumer.Id))
+	for _, authenticator := range a.authenticators {
+		authenticator.StripCredential(authenticatedRequest)
+	}
+
+	authenticatedRequest.Header.Set(a.consumerHeader, consumer.Id)
+	for name, values := range consumer.Headers {
+		authenticatedRequest.Header[name] = values
+	}
+
+	return authenticatedRequest
+}
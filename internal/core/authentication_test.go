@@ -0,0 +1,50 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticationShouldNotModifyOriginalRequest(t *testing.T) {
+	// arrange
+	apiKeys := CreateApiKeyAuthenticator("goprx", "X-Api-Key", "api_key", map[string]string{"secret-key": "billing-service"})
+
+	var forwardedRequest *http.Request
+	handler := createTestAuthenticationMiddleware("X-Consumer", apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedRequest = r
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices?api_key=secret-key&page=2", nil)
+	request.Header.Set("X-Api-Key", "secret-key")
+	request.Header.Set("X-Consumer", "spoofed")
+
+	// act
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	// assert
+	require.NotNil(t, forwardedRequest)
+	assert.Equal(t, "billing-service", forwardedRequest.Header.Get("X-Consumer"))
+	assert.Equal(t, "page=2", forwardedRequest.URL.RawQuery)
+	assert.Equal(t, "secret-key", request.Header.Get("X-Api-Key"))
+	assert.Equal(t, "spoofed", request.Header.Get("X-Consumer"))
+	assert.Equal(t, "api_key=secret-key&page=2", request.URL.RawQuery)
+	assert.Empty(t, ConsumerFromContext(request.Context()))
+}
+
+func TestCreateAuthenticationShouldRejectInvalidConfig(t *testing.T) {
+	apiKeys := CreateApiKeyAuthenticator("goprx", "X-Api-Key", "", map[string]string{"secret-key": "billing-service"})
+
+	_, err := CreateAuthentication("", apiKeys)
+	assert.Error(t, err)
+
+	_, err = CreateAuthentication("X-Consumer")
+	assert.Error(t, err)
+}
+
+func createTestAuthenticationMiddleware(consumerHeader string, authenticators ...Authenticator) Middleware {
+	authentication, _ := CreateAuthentication(consumerHeader, authenticators...)
+	return authentication.Middleware()
+}
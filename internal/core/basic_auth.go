@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dummyBcryptHash is compared to the passwords of unknown users so they take as long to reject as the known ones
+var dummyBcryptHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("goprx"), bcrypt.DefaultCost)
+	return hash
+})
+
+// BasicAuthenticator checks the Basic credentials against the bcrypt hashes of an htpasswd file,
+// the file is reloaded when its modification time or its size change
+type BasicAuthenticator struct {
+	logger        *slog.Logger
+	realm         string
+	path          string
+	checkInterval time.Duration
+
+	mutex     sync.RWMutex
+	users     map[string][]byte
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+func CreateBasicAuthenticator(realm string, htpasswdPath string, logger *slog.Logger) (*BasicAuthenticator, error) {
+	authenticator := &BasicAuthenticator{
+		logger:        logger,
+		realm:         realm,
+		path:          htpasswdPath,
+		checkInterval: time.Second,
+	}
+
+	info, err := os.Stat(htpasswdPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read htpasswd file: %w", err)
+	}
+
+	if err := authenticator.load(info); err != nil {
+		return nil, err
+	}
+
+	return authenticator, nil
+}
+
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*Consumer, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, UnauthorizedErr
+	}
+
+	a.reloadIfChanged(r)
+
+	a.mutex.RLock()
+	hash, found := a.users[username]
+	a.mutex.RUnlock()
+
+	if !found {
+		_ = bcrypt.CompareHashAndPassword(dummyBcryptHash(), []byte(password))
+		return nil, UnauthorizedErr
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return nil, UnauthorizedErr
+	}
+
+	return &Consumer{Id: username}, nil
+}
+
+func (a *BasicAuthenticator) StripCredential(r *http.Request) {
+	r.Header.Del("Authorization")
+}
+
+func (a *BasicAuthenticator) Challenge() string {
+	return fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm)
+}
+
+// reloadIfChanged checks the file at most once per check interval, the previous users are kept when it is invalid
+func (a *BasicAuthenticator) reloadIfChanged(r *http.Request) {
+	a.mutex.RLock()
+	due := time.Since(a.checkedAt) >= a.checkInterval
+	a.mutex.RUnlock()
+
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(a.path)
+
+	a.mutex.Lock()
+	a.checkedAt = time.Now()
+	changed := err == nil && (!info.ModTime().Equal(a.modTime) || info.Size() != a.size)
+	a.mutex.Unlock()
+
+	if err != nil {
+		a.logger.Log(r.Context(), slog.LevelError, "cannot check htpasswd file, previous users kept", slog.Any("error", err))
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := a.load(info); err != nil {
+		a.logger.Log(r.Context(), slog.LevelError, "cannot reload htpasswd file, previous users kept", slog.Any("error", err))
+		return
+	}
+
+	a.logger.Log(r.Context(), slog.LevelInfo, "htpasswd file reloaded")
+}
+
+func (a *BasicAuthenticator) load(info os.FileInfo) error {
+	content, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("cannot read htpasswd file: %w", err)
+	}
+
+	users, err := parseHtpasswd(content)
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	a.users = users
+	a.modTime = info.ModTime()
+	a.size = info.Size()
+	a.checkedAt = time.Now()
+	a.mutex.Unlock()
+
+	return nil
+}
+
+// parseHtpasswd reads the user:hash lines, only bcrypt hashes are supported
+func parseHtpasswd(content []byte) (map[string][]byte, error) {
+	users := make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		username, hash, found := strings.Cut(entry, ":")
+		if !found || username == "" {
+			return nil, fmt.Errorf("invalid htpasswd entry at line %d", line)
+		}
+
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("htpasswd entry of %q at line %d is not a bcrypt hash", username, line)
+		}
+
+		users[username] = []byte(hash)
+	}
+
+	return users, scanner.Err()
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBasicAuthenticator(t *testing.T) {
+	// arrange
+	path := writeTestHtpasswd(t, filepath.Join(t.TempDir(), ".htpasswd"), "# users\nalice:"+createTestBcryptHash("wonderland")+"\n")
+	authenticator, err := CreateBasicAuthenticator("goprx", path, createTestErrorLogger())
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		Username, Password string
+		ExpectedConsumer   string
+		ExpectedErr        error
+	}{
+		"should authenticate user":       {Username: "alice", Password: "wonderland", ExpectedConsumer: "alice"},
+		"should reject invalid password": {Username: "alice", Password: "invalid", ExpectedErr: UnauthorizedErr},
+		"should reject unknown user":     {Username: "bob", Password: "wonderland", ExpectedErr: UnauthorizedErr},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.SetBasicAuth(test.Username, test.Password)
+
+			// act
+			consumer, err := authenticator.Authenticate(request)
+
+			// assert
+			assert.ErrorIs(t, err, test.ExpectedErr)
+			if test.ExpectedErr == nil {
+				assert.Equal(t, test.ExpectedConsumer, consumer.Id)
+			}
+		})
+	}
+}
+
+func TestBasicAuthenticatorShouldReloadChangedFile(t *testing.T) {
+	// arrange
+	path := writeTestHtpasswd(t, filepath.Join(t.TempDir(), ".htpasswd"), "alice:"+createTestBcryptHash("wonderland")+"\n")
+	authenticator, err := CreateBasicAuthenticator("goprx", path, createTestErrorLogger())
+	require.NoError(t, err)
+	authenticator.checkInterval = 0
+
+	writeTestHtpasswd(t, path, "bob:"+createTestBcryptHash("builder")+"\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	request.SetBasicAuth("bob", "builder")
+	formerRequest := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	formerRequest.SetBasicAuth("alice", "wonderland")
+
+	// act
+	consumer, err := authenticator.Authenticate(request)
+	_, formerErr := authenticator.Authenticate(formerRequest)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "bob", consumer.Id)
+	assert.ErrorIs(t, formerErr, UnauthorizedErr)
+}
+
+func TestBasicAuthenticatorShouldKeepUsersOfInvalidFile(t *testing.T) {
+	// arrange
+	path := writeTestHtpasswd(t, filepath.Join(t.TempDir(), ".htpasswd"), "alice:"+createTestBcryptHash("wonderland")+"\n")
+	authenticator, _ := CreateBasicAuthenticator("goprx", path, createTestErrorLogger())
+	authenticator.checkInterval = 0
+
+	writeTestHtpasswd(t, path, "alice:{SHA}plain\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	request.SetBasicAuth("alice", "wonderland")
+
+	// act
+	consumer, err := authenticator.Authenticate(request)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "alice", consumer.Id)
+}
+
+func TestAuthenticationMiddleware(t *testing.T) {
+	// arrange
+	path := writeTestHtpasswd(t, filepath.Join(t.TempDir(), ".htpasswd"), "alice:"+createTestBcryptHash("wonderland")+"\n")
+	basic, _ := CreateBasicAuthenticator("goprx", path, createTestErrorLogger())
+	apiKeys := CreateApiKeyAuthenticator("goprx", "X-Api-Key", "api_key", map[string]string{"secret-key": "billing-service"})
+
+	var forwardedRequest *http.Request
+	handler := createTestAuthenticationMiddleware("X-Consumer", basic, apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedRequest = r
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("should forward consumer of basic credentials", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		request.SetBasicAuth("alice", "wonderland")
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "alice", forwardedRequest.Header.Get("X-Consumer"))
+		assert.Equal(t, "alice", ConsumerFromContext(forwardedRequest.Context()))
+		assert.Empty(t, forwardedRequest.Header.Get("Authorization"))
+	})
+
+	t.Run("should forward consumer of api key and strip it from query", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices?api_key=secret-key&page=2", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "billing-service", forwardedRequest.Header.Get("X-Consumer"))
+		assert.Equal(t, "page=2", forwardedRequest.URL.RawQuery)
+	})
+
+	t.Run("should strip only the api key from query", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices?b=%7E1&api_key=secret-key&a=x+y&api%5Fkey=other&c", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "b=%7E1&a=x+y&c", forwardedRequest.URL.RawQuery)
+	})
+
+	t.Run("should strip api key from query when read from header", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices?api_key=leaked-key&page=2", nil)
+		request.Header.Set("X-Api-Key", "secret-key")
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Empty(t, forwardedRequest.Header.Get("X-Api-Key"))
+		assert.Equal(t, "page=2", forwardedRequest.URL.RawQuery)
+	})
+
+	t.Run("should reject request without valid credential", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		request.Header.Set("X-Api-Key", "unknown")
+		request.Header.Set("X-Consumer", "spoofed")
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+		assert.Equal(t, []string{`Basic realm="goprx", charset="UTF-8"`, `ApiKey realm="goprx"`}, response.Header().Values("WWW-Authenticate"))
+	})
+}
+
+func TestCreateBasicAuthenticatorShouldRejectInvalidFile(t *testing.T) {
+	_, err := CreateBasicAuthenticator("goprx", filepath.Join(t.TempDir(), "missing"), createTestErrorLogger())
+	assert.Error(t, err)
+
+	path := writeTestHtpasswd(t, filepath.Join(t.TempDir(), ".htpasswd"), "alice:$apr1$plain\n")
+	_, err = CreateBasicAuthenticator("goprx", path, createTestErrorLogger())
+	assert.Error(t, err)
+}
+
+func writeTestHtpasswd(t *testing.T, path string, content string) string {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func createTestBcryptHash(password string) string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(hash)
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const ForwardedForHeader = "X-Forwarded-For"
+
+// ClientIpResolver resolves the client address from the X-Forwarded-For header,
+// the header is only trusted when the request comes from one of the trusted proxies
+type ClientIpResolver struct {
+	trustedProxies []netip.Prefix
+}
+
+func CreateClientIpResolver(trustedProxies []string) (*ClientIpResolver, error) {
+	prefixes, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	return &ClientIpResolver{
+		trustedProxies: prefixes,
+	}, nil
+}
+
+// Resolve walks the X-Forwarded-For header from the closest hop and returns the first address which is not a trusted proxy
+func (c *ClientIpResolver) Resolve(r *http.Request) netip.Addr {
+	client, ok := parseRemoteAddr(r.RemoteAddr)
+	if !ok || !c.isTrustedProxy(client) {
+		return client
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseRemoteAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			return client
+		}
+
+		client = hop
+		if !c.isTrustedProxy(client) {
+			return client
+		}
+	}
+
+	return client
+}
+
+func (c *ClientIpResolver) isTrustedProxy(addr netip.Addr) bool {
+	return containsAddr(c.trustedProxies, addr)
+}
+
+// ClientIp returns the address resolved by the client ip middleware of the reverse proxy, or the remote address when the request did not go through it
+func ClientIp(r *http.Request) netip.Addr {
+	if addr, ok := ClientIpFromContext(r.Context()); ok {
+		return addr
+	}
+
+	addr, _ := parseRemoteAddr(r.RemoteAddr)
+	return addr
+}
+
+// ParsePrefixes parses CIDR ranges, a single address is parsed as the range only containing it
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, err
+			}
+
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remoteAddr = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(remoteAddr, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap().WithZone(""), true
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIpResolver(t *testing.T) {
+	testCases := []struct {
+		Name, RemoteAddr, ForwardedFor, ExpectedClientIp string
+	}{
+		{"should use remote address without forwarded header", "203.0.113.7:5432", "", "203.0.113.7"},
+		{"should ignore forwarded header from untrusted remote address", "203.0.113.7:5432", "198.51.100.1", "203.0.113.7"},
+		{"should use forwarded address from trusted proxy", "10.0.0.1:5432", "198.51.100.1", "198.51.100.1"},
+		{"should skip trusted proxies in forwarded chain", "10.0.0.1:5432", "198.51.100.1, 10.0.0.2", "198.51.100.1"},
+		{"should not trust addresses before the first untrusted hop", "10.0.0.1:5432", "192.0.2.66, 198.51.100.1", "198.51.100.1"},
+		{"should stop at invalid forwarded hop", "10.0.0.1:5432", "198.51.100.1, garbage", "10.0.0.1"},
+		{"should resolve ipv6 addresses", "[fd00::1]:5432", "2001:db8::42", "2001:db8::42"},
+		{"should unmap ipv4 mapped addresses", "[::ffff:203.0.113.7]:5432", "", "203.0.113.7"},
+	}
+
+	resolver, err := CreateClientIpResolver([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.RemoteAddr = test.RemoteAddr
+			if test.ForwardedFor != "" {
+				request.Header.Set(ForwardedForHeader, test.ForwardedFor)
+			}
+
+			// act
+			clientIp := resolver.Resolve(request)
+
+			// assert
+			assert.Equal(t, test.ExpectedClientIp, clientIp.String())
+		})
+	}
+}
+
+func TestIpAccessPolicy(t *testing.T) {
+	testCases := []struct {
+		Name, RemoteAddr string
+		Allow, Deny      []string
+		ExpectedStatus   int
+	}{
+		{"should allow any address without lists", "203.0.113.7:80", nil, nil, http.StatusOK},
+		{"should allow address in allow list", "192.168.1.20:80", []string{"192.168.1.0/24"}, nil, http.StatusOK},
+		{"should forbid address outside allow list", "203.0.113.7:80", []string{"192.168.1.0/24"}, nil, http.StatusForbidden},
+		{"should forbid denied address even when allowed", "192.168.1.20:80", []string{"192.168.1.0/24"}, []string{"192.168.1.20"}, http.StatusForbidden},
+		{"should allow ipv6 address in allow list", "[2001:db8::1]:80", []string{"2001:db8::/32"}, nil, http.StatusOK},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			policy, err := CreateIpAccessPolicy(test.Allow, test.Deny)
+			require.NoError(t, err)
+
+			handler := CreateIpAccessPolicyMiddleware(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/admin", nil)
+			request.RemoteAddr = test.RemoteAddr
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+}
+
+func TestIpAccessPolicyCreation(t *testing.T) {
+	_, err := CreateIpAccessPolicy([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+
+	_, err = CreateIpAccessPolicy(nil, []string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ConcurrencyLimitErr = errors.New("too many requests in flight")
+
+type ConcurrencyLimitConfig struct {
+	// MaxInFlight is the limit of requests handled at once, the initial one in adaptive mode
+	MaxInFlight int
+	// MaxQueued requests wait, in FIFO order, up to QueueTimeoutInMs for a slot before being rejected
+	MaxQueued        int
+	QueueTimeoutInMs time.Duration
+
+	// Adaptive adjusts the limit between MinInFlight and MaxAdaptiveInFlight from the observed latencies:
+	// it is increased by one while the limit is reached and the latencies stay below LatencyTolerance times
+	// the no-load latency, it is multiplied by BackoffRatio when they exceed it or when the request fails
+	Adaptive            bool
+	MinInFlight         int
+	MaxAdaptiveInFlight int
+	LatencyTolerance    float64
+	BackoffRatio        float64
+	// LatencyWindow is the number of requests after which the no-load latency is measured again
+	LatencyWindow int
+}
+
+func CreateConcurrencyLimitConfig(maxInFlight int) *ConcurrencyLimitConfig {
+	return &ConcurrencyLimitConfig{
+		MaxInFlight:      maxInFlight,
+		MaxQueued:        maxInFlight,
+		QueueTimeoutInMs: 1000,
+	}
+}
+
+func CreateAdaptiveConcurrencyLimitConfig(initialInFlight int, minInFlight int, maxInFlight int) *ConcurrencyLimitConfig {
+	config := CreateConcurrencyLimitConfig(initialInFlight)
+	config.Adaptive = true
+	config.MinInFlight = minInFlight
+	config.MaxAdaptiveInFlight = maxInFlight
+	config.LatencyTolerance = 2
+	config.BackoffRatio = 0.9
+	config.LatencyWindow = 100
+
+	return config
+}
+
+// ConcurrencyLimiter bounds the requests in flight, the waiting ones are granted a slot in their arrival order
+type ConcurrencyLimiter struct {
+	logger   *slog.Logger
+	config   *ConcurrencyLimitConfig
+	mutex    sync.Mutex
+	limit    int
+	inFlight int
+	queue    *list.List
+
+	noLoadLatency time.Duration
+	windowLatency time.Duration
+	windowSamples int
+}
+
+// ConcurrencyPermit holds a slot of the limiter until it is released
+type ConcurrencyPermit struct {
+	limiter *ConcurrencyLimiter
+	start   time.Time
+	once    sync.Once
+}
+
+func CreateConcurrencyLimiter(config *ConcurrencyLimitConfig, logger *slog.Logger) (*ConcurrencyLimiter, error) {
+	if config.MaxInFlight <= 0 || config.MaxQueued < 0 {
+		return nil, fmt.Errorf("concurrency limit must be positive and its queue cannot be negative")
+	}
+
+	if config.Adaptive && (config.MinInFlight <= 0 || config.MinInFlight > config.MaxInFlight || config.MaxInFlight > config.MaxAdaptiveInFlight) {
+		return nil, fmt.Errorf("adaptive concurrency limit must be between its positive minimum and its maximum")
+	}
+
+	return &ConcurrencyLimiter{
+		logger: logger,
+		config: config,
+		limit:  config.MaxInFlight,
+		queue:  list.New(),
+	}, nil
+}
+
+// Limit returns the current limit of requests in flight
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.limit
+}
+
+// Acquire waits for a slot, ConcurrencyLimitErr is returned when the queue is full, when the wait times out
+// or when the context is done
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (*ConcurrencyPermit, error) {
+	l.mutex.Lock()
+	if l.inFlight < l.limit && l.queue.Len() == 0 {
+		l.inFlight++
+		l.mutex.Unlock()
+		return l.permit(), nil
+	}
+
+	if l.queue.Len() >= l.config.MaxQueued {
+		l.mutex.Unlock()
+		l.logger.Log(ctx, slog.LevelWarn, "concurrency limit queue is full, request rejected")
+		return nil, ConcurrencyLimitErr
+	}
+
+	granted := make(chan struct{})
+	waiter := l.queue.PushBack(granted)
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(l.config.QueueTimeoutInMs * time.Millisecond)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-granted:
+		return l.permit(), nil
+	case <-timer.C:
+		err = ConcurrencyLimitErr
+	case <-ctx.Done():
+		// the client gave up waiting, the request is rejected as any other one not granted a slot
+		err = fmt.Errorf("%w: %w", ConcurrencyLimitErr, ctx.Err())
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	select {
+	case <-granted:
+		// the slot was granted while giving up, it is given back to the next waiter
+		l.inFlight--
+		l.grantWaiters()
+	default:
+		l.queue.Remove(waiter)
+	}
+
+	l.logger.Log(ctx, slog.LevelWarn, "request not granted a slot of the concurrency limit", slog.Any("error", err))
+	return nil, err
+}
+
+func (l *ConcurrencyLimiter) permit() *ConcurrencyPermit {
+	return &ConcurrencyPermit{limiter: l, start: time.Now()}
+}
+
+// Release frees the slot, failed requests are considered as overloading the upstream in adaptive mode
+func (p *ConcurrencyPermit) Release(failed bool) {
+	p.once.Do(func() {
+		p.limiter.release(time.Since(p.start), failed)
+	})
+}
+
+func (l *ConcurrencyLimiter) release(latency time.Duration, failed bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.config.Adaptive {
+		l.adjustLimit(latency, failed)
+	}
+
+	l.inFlight--
+	l.grantWaiters()
+}
+
+func (l *ConcurrencyLimiter) grantWaiters() {
+	for l.inFlight < l.limit && l.queue.Len() > 0 {
+		granted := l.queue.Remove(l.queue.Front()).(chan struct{})
+		l.inFlight++
+		close(granted)
+	}
+}
+
+// adjustLimit increases the limit additively and decreases it multiplicatively
+func (l *ConcurrencyLimiter) adjustLimit(latency time.Duration, failed bool) {
+	l.sampleLatency(latency)
+
+	overloaded := failed || float64(latency) > float64(l.noLoadLatency)*l.config.LatencyTolerance
+	switch {
+	case overloaded:
+		l.limit = max(l.config.MinInFlight, int(float64(l.limit)*l.config.BackoffRatio))
+	case l.inFlight >= l.limit:
+		l.limit = min(l.config.MaxAdaptiveInFlight, l.limit+1)
+	}
+}
+
+// sampleLatency keeps the lowest latency observed, measured again every LatencyWindow requests
+// so the no-load latency follows the upstream when it becomes slower
+func (l *ConcurrencyLimiter) sampleLatency(latency time.Duration) {
+	if l.windowSamples == 0 || latency < l.windowLatency {
+		l.windowLatency = latency
+	}
+
+	if l.noLoadLatency == 0 || latency < l.noLoadLatency {
+		l.noLoadLatency = latency
+	}
+
+	l.windowSamples++
+	if l.windowSamples >= max(l.config.LatencyWindow, 1) {
+		l.noLoadLatency = l.windowLatency
+		l.windowSamples = 0
+	}
+}
+
+func (l *ConcurrencyLimiter) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permit, err := l.Acquire(r.Context())
+			if err != nil {
+				_ = WriteError(w, r, err)
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w}
+			failed := true
+			defer func() { permit.Release(failed) }()
+
+			next.ServeHTTP(recorder, r)
+			failed = recorder.status >= http.StatusInternalServerError
+		})
+	}
+}
+
+// releasingBody releases the permit once the upstream response has been read
+type releasingBody struct {
+	io.ReadCloser
+	permit *ConcurrencyPermit
+	failed bool
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.permit.Release(b.failed)
+	return err
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiterShouldGrantQueuedRequestsInOrder(t *testing.T) {
+	// arrange
+	config := CreateConcurrencyLimitConfig(1)
+	config.MaxQueued = 2
+	limiter, err := CreateConcurrencyLimiter(config, createTestErrorLogger())
+	require.NoError(t, err)
+
+	permit, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+
+	granted := make(chan int, 2)
+	var waiting sync.WaitGroup
+	for i := 1; i <= 2; i++ {
+		waiting.Add(1)
+		go func() {
+			defer waiting.Done()
+			queued, err := limiter.Acquire(context.Background())
+			if err == nil {
+				granted <- i
+				queued.Release(false)
+			}
+		}()
+
+		waitForQueuedRequests(t, limiter, i)
+	}
+
+	// act
+	_, rejectedErr := limiter.Acquire(context.Background())
+	permit.Release(false)
+	waiting.Wait()
+
+	// assert
+	assert.ErrorIs(t, rejectedErr, ConcurrencyLimitErr)
+	assert.Equal(t, 1, <-granted)
+	assert.Equal(t, 2, <-granted)
+}
+
+func TestConcurrencyLimiterShouldRejectAfterQueueTimeout(t *testing.T) {
+	// arrange
+	config := CreateConcurrencyLimitConfig(1)
+	config.QueueTimeoutInMs = 10
+	limiter, _ := CreateConcurrencyLimiter(config, createTestErrorLogger())
+	permit, _ := limiter.Acquire(context.Background())
+
+	// act
+	_, err := limiter.Acquire(context.Background())
+	permit.Release(false)
+	next, nextErr := limiter.Acquire(context.Background())
+
+	// assert
+	assert.ErrorIs(t, err, ConcurrencyLimitErr)
+	require.NoError(t, nextErr)
+	next.Release(false)
+}
+
+func TestConcurrencyLimiterShouldRejectCanceledQueuedRequest(t *testing.T) {
+	// arrange
+	limiter, _ := CreateConcurrencyLimiter(CreateConcurrencyLimitConfig(1), createTestErrorLogger())
+	permit, _ := limiter.Acquire(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rejected := make(chan error)
+	go func() {
+		_, err := limiter.Acquire(ctx)
+		rejected <- err
+	}()
+	waitForQueuedRequests(t, limiter, 1)
+
+	// act
+	cancel()
+	err := <-rejected
+	permit.Release(false)
+	next, nextErr := limiter.Acquire(context.Background())
+
+	// assert
+	assert.ErrorIs(t, err, ConcurrencyLimitErr)
+	assert.ErrorIs(t, err, context.Canceled)
+	require.NoError(t, nextErr)
+	next.Release(false)
+}
+
+func TestConcurrencyLimiterMiddleware(t *testing.T) {
+	// arrange
+	config := CreateConcurrencyLimitConfig(1)
+	config.MaxQueued = 0
+	limiter, _ := CreateConcurrencyLimiter(config, createTestErrorLogger())
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	blocked := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(blocked, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+		close(done)
+	}()
+	<-entered
+
+	rejected := httptest.NewRecorder()
+
+	// act
+	handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	close(unblock)
+	<-done
+
+	// assert
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+	assert.Equal(t, http.StatusOK, blocked.Code)
+}
+
+func TestAdaptiveConcurrencyLimiter(t *testing.T) {
+	// arrange
+	limiter, err := CreateConcurrencyLimiter(CreateAdaptiveConcurrencyLimitConfig(10, 2, 12), createTestErrorLogger())
+	require.NoError(t, err)
+
+	saturate := func() {
+		for range limiter.Limit() {
+			_, _ = limiter.Acquire(context.Background())
+		}
+	}
+
+	// act
+	saturate()
+	limiter.release(10*time.Millisecond, false)
+	increased := limiter.Limit()
+
+	limiter.release(50*time.Millisecond, false)
+	decreasedBySlowRequest := limiter.Limit()
+
+	limiter.release(10*time.Millisecond, true)
+	decreasedByFailedRequest := limiter.Limit()
+
+	// assert
+	assert.Equal(t, 11, increased)
+	assert.Equal(t, 9, decreasedBySlowRequest)
+	assert.Equal(t, 8, decreasedByFailedRequest)
+}
+
+func TestConcurrencyLimiterCreation(t *testing.T) {
+	_, err := CreateConcurrencyLimiter(CreateConcurrencyLimitConfig(0), createTestErrorLogger())
+	assert.Error(t, err)
+
+	_, err = CreateConcurrencyLimiter(CreateAdaptiveConcurrencyLimitConfig(10, 20, 30), createTestErrorLogger())
+	assert.Error(t, err)
+}
+
+func waitForQueuedRequests(t *testing.T, limiter *ConcurrencyLimiter, count int) {
+	require.Eventually(t, func() bool {
+		limiter.mutex.Lock()
+		defer limiter.mutex.Unlock()
+
+		return limiter.queue.Len() == count
+	}, time.Second, time.Millisecond)
+}
+
+func TestServiceBalancerConcurrencyLimiter(t *testing.T) {
+	// arrange
+	logger := createTestErrorLogger()
+	sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 1, 1), logger)
+	sb.RegisterService(context.Background(), createTestService(handlerWithStatusCode(http.StatusOK)))
+	waitForAllServicesToBeAvailable(sb)
+
+	config := CreateConcurrencyLimitConfig(1)
+	config.MaxQueued = 0
+	limiter, _ := CreateConcurrencyLimiter(config, logger)
+	sb.SetConcurrencyLimiter(limiter)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+
+	// act
+	resp, err := sb.HandleRequest(context.Background(), request)
+	require.NoError(t, err)
+	_, limitedErr := sb.HandleRequest(context.Background(), request)
+	_ = resp.Body.Close()
+	next, nextErr := sb.HandleRequest(context.Background(), request)
+
+	// assert
+	assert.ErrorIs(t, limitedErr, ConcurrencyLimitErr)
+	require.NoError(t, nextErr)
+	_ = next.Body.Close()
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var NoMatchingApplicationErr = errors.New("no matching application found")
+var InternalErr = errors.New("internal error")
+var InvalidRewrittenPathErr = errors.New("rewritten path is not a valid escaped path")
+
+type errorDefinition struct {
+	err    error
+	status int
+	code   string
+}
+
+var errorDefinitions = []errorDefinition{
+	{err: ServiceUnavailableErr, status: http.StatusServiceUnavailable, code: "service_unavailable"},
+	{err: BadGatewayErr, status: http.StatusBadGateway, code: "bad_gateway"},
+	{err: GatewayTimeoutErr, status: http.StatusGatewayTimeout, code: "gateway_timeout"},
+	{err: NoMatchingApplicationErr, status: http.StatusNotFound, code: "no_matching_application"},
+	{err: InvalidRewrittenPathErr, status: http.StatusInternalServerError, code: "invalid_rewritten_path"},
+	{err: UnauthorizedErr, status: http.StatusUnauthorized, code: "unauthorized"},
+	{err: ForbiddenErr, status: http.StatusForbidden, code: "forbidden"},
+	{err: FileNotFoundErr, status: http.StatusNotFound, code: "file_not_found"},
+	{err: MethodNotAllowedErr, status: http.StatusMethodNotAllowed, code: "method_not_allowed"},
+	{err: TooManyRequestsErr, status: http.StatusTooManyRequests, code: "too_many_requests"},
+	{err: ConcurrencyLimitErr, status: http.StatusServiceUnavailable, code: "concurrency_limit_exceeded"},
+	{err: RequestBodyTooLargeErr, status: http.StatusRequestEntityTooLarge, code: "request_body_too_large"},
+	{err: RequestHeaderFieldsTooLargeErr, status: http.StatusRequestHeaderFieldsTooLarge, code: "request_header_fields_too_large"},
+	{err: UriTooLongErr, status: http.StatusRequestURITooLong, code: "uri_too_long"},
+	{err: InternalErr, status: http.StatusInternalServerError, code: "internal_error"},
+}
+
+type ProxyError struct {
+	Status    int
+	Code      string
+	Title     string
+	Detail    string
+	Instance  string
+	RequestId string
+}
+
+// CreateProxyError only exposes the message of the known error wrapped by err, internal details are never rendered
+func CreateProxyError(r *http.Request, err error) *ProxyError {
+	definition := errorDefinitions[len(errorDefinitions)-1]
+	for _, errorDefinition := range errorDefinitions {
+		if errors.Is(err, errorDefinition.err) {
+			definition = errorDefinition
+			break
+		}
+	}
+
+	return &ProxyError{
+		Status:    definition.status,
+		Code:      definition.code,
+		Title:     http.StatusText(definition.status),
+		Detail:    definition.err.Error(),
+		Instance:  r.URL.Path,
+		RequestId: RequestIdFromContext(r.Context()),
+	}
+}
+
+func WriteError(w http.ResponseWriter, r *http.Request, err error) error {
+	return ErrorRendererFromContext(r.Context()).Render(w, r, CreateProxyError(r, err))
+}
+
+type ErrorRenderer interface {
+	Render(w http.ResponseWriter, r *http.Request, proxyErr *ProxyError) error
+}
+
+type TextErrorRenderer struct{}
+
+func (t *TextErrorRenderer) Render(w http.ResponseWriter, _ *http.Request, proxyErr *ProxyError) error {
+	writeErrorHeaders(w, "text/plain; charset=utf-8", proxyErr.Status)
+	_, err := fmt.Fprintf(w, "%s\ncode: %s\nrequest_id: %s\n", proxyErr.Detail, proxyErr.Code, proxyErr.RequestId)
+	return err
+}
+
+type ProblemJsonErrorRenderer struct{}
+
+type problemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance"`
+	Code      string `json:"code"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+func (p *ProblemJsonErrorRenderer) Render(w http.ResponseWriter, _ *http.Request, proxyErr *ProxyError) error {
+	content, err := json.Marshal(&problemDetails{
+		Type:      "about:blank",
+		Title:     proxyErr.Title,
+		Status:    proxyErr.Status,
+		Detail:    proxyErr.Detail,
+		Instance:  proxyErr.Instance,
+		Code:      proxyErr.Code,
+		RequestId: proxyErr.RequestId,
+	})
+	if err != nil {
+		return err
+	}
+
+	writeErrorHeaders(w, "application/problem+json", proxyErr.Status)
+	_, err = w.Write(content)
+	return err
+}
+
+const defaultHtmlErrorTemplate = `<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.Title}}</title></head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>{{.Detail}}</p>
+<p>code: {{.Code}}</p>
+{{if .RequestId}}<p>request id: {{.RequestId}}</p>{{end}}
+</body>
+</html>
+`
+
+type HtmlErrorRenderer struct {
+	template *template.Template
+}
+
+func CreateHtmlErrorRenderer(htmlTemplate string) (*HtmlErrorRenderer, error) {
+	compiledTemplate, err := template.New("error").Parse(htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HtmlErrorRenderer{
+		template: compiledTemplate,
+	}, nil
+}
+
+func (h *HtmlErrorRenderer) Render(w http.ResponseWriter, _ *http.Request, proxyErr *ProxyError) error {
+	var content strings.Builder
+	if err := h.template.Execute(&content, proxyErr); err != nil {
+		return err
+	}
+
+	writeErrorHeaders(w, "text/html; charset=utf-8", proxyErr.Status)
+	_, err := w.Write([]byte(content.String()))
+	return err
+}
+
+type NegotiatedErrorRenderer struct {
+	defaultRenderer ErrorRenderer
+	mediaTypes      []string
+	renderers       map[string]ErrorRenderer
+}
+
+// CreateNegotiatedErrorRenderer picks the renderer from the request's Accept header,
+// defaultRenderer is used when no registered media type is acceptable
+func CreateNegotiatedErrorRenderer(defaultRenderer ErrorRenderer) *NegotiatedErrorRenderer {
+	return &NegotiatedErrorRenderer{
+		defaultRenderer: defaultRenderer,
+		mediaTypes:      make([]string, 0),
+		renderers:       make(map[string]ErrorRenderer),
+	}
+}
+
+func (n *NegotiatedErrorRenderer) Register(mediaType string, renderer ErrorRenderer) *NegotiatedErrorRenderer {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := n.renderers[mediaType]; !ok {
+		n.mediaTypes = append(n.mediaTypes, mediaType)
+	}
+
+	n.renderers[mediaType] = renderer
+	return n
+}
+
+func (n *NegotiatedErrorRenderer) Render(w http.ResponseWriter, r *http.Request, proxyErr *ProxyError) error {
+	w.Header().Add("Vary", "Accept")
+	return n.negotiate(r.Header.Get("Accept")).Render(w, r, proxyErr)
+}
+
+func (n *NegotiatedErrorRenderer) negotiate(accept string) ErrorRenderer {
+	for _, acceptedMediaType := range parseAcceptHeader(accept) {
+		if acceptedMediaType == "*/*" {
+			return n.defaultRenderer
+		}
+
+		for _, mediaType := range n.mediaTypes {
+			if acceptedMediaType == mediaType || (strings.HasSuffix(acceptedMediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(acceptedMediaType, "*"))) {
+				return n.renderers[mediaType]
+			}
+		}
+	}
+
+	return n.defaultRenderer
+}
+
+func CreateDefaultErrorRenderer() ErrorRenderer {
+	htmlRenderer, _ := CreateHtmlErrorRenderer(defaultHtmlErrorTemplate)
+	jsonRenderer := &ProblemJsonErrorRenderer{}
+	textRenderer := &TextErrorRenderer{}
+
+	return CreateNegotiatedErrorRenderer(textRenderer).
+		Register("text/plain", textRenderer).
+		Register("application/problem+json", jsonRenderer).
+		Register("application/json", jsonRenderer).
+		Register("text/html", htmlRenderer)
+}
+
+var defaultErrorRenderer = CreateDefaultErrorRenderer()
+
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAcceptHeader returns the accepted media types ordered by decreasing quality
+func parseAcceptHeader(accept string) []string {
+	acceptedMediaTypes := make([]acceptedMediaType, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality <= 0 {
+				continue
+			}
+		}
+
+		acceptedMediaTypes = append(acceptedMediaTypes, acceptedMediaType{mediaType: mediaType, quality: quality})
+	}
+
+	slices.SortStableFunc(acceptedMediaTypes, func(a, b acceptedMediaType) int {
+		if a.quality > b.quality {
+			return -1
+		}
+
+		if a.quality < b.quality {
+			return 1
+		}
+
+		return 0
+	})
+
+	mediaTypes := make([]string, len(acceptedMediaTypes))
+	for i, accepted := range acceptedMediaTypes {
+		mediaTypes[i] = accepted.mediaType
+	}
+
+	return mediaTypes
+}
+
+func writeErrorHeaders(w http.ResponseWriter, contentType string, status int) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+}
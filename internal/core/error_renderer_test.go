@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorRenderer(t *testing.T) {
+	endpointUrl := "http://localhost/simple-query"
+
+	testCases := []struct {
+		Name                string
+		Accept              string
+		Err                 error
+		ExpectedStatus      int
+		ExpectedContentType string
+		ExpectedContent     string
+	}{
+		{
+			Name:                "should render plain text by default",
+			Err:                 ServiceUnavailableErr,
+			ExpectedStatus:      http.StatusServiceUnavailable,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			ExpectedContent:     "code: service_unavailable",
+		},
+		{
+			Name:                "should render problem json when accepted",
+			Accept:              "application/problem+json",
+			Err:                 BadGatewayErr,
+			ExpectedStatus:      http.StatusBadGateway,
+			ExpectedContentType: "application/problem+json",
+			ExpectedContent:     `"code":"bad_gateway"`,
+		},
+		{
+			Name:                "should render problem json when json is accepted",
+			Accept:              "text/html;q=0.5, application/json",
+			Err:                 GatewayTimeoutErr,
+			ExpectedStatus:      http.StatusGatewayTimeout,
+			ExpectedContentType: "application/problem+json",
+			ExpectedContent:     `"code":"gateway_timeout"`,
+		},
+		{
+			Name:                "should render html when accepted",
+			Accept:              "text/html,application/xhtml+xml,*/*;q=0.8",
+			Err:                 ServiceUnavailableErr,
+			ExpectedStatus:      http.StatusServiceUnavailable,
+			ExpectedContentType: "text/html; charset=utf-8",
+			ExpectedContent:     "<h1>503 Service Unavailable</h1>",
+		},
+		{
+			Name:                "should render plain text when nothing registered is accepted",
+			Accept:              "image/png",
+			Err:                 BadGatewayErr,
+			ExpectedStatus:      http.StatusBadGateway,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			ExpectedContent:     "code: bad_gateway",
+		},
+		{
+			Name:                "should not leak wrapped error messages",
+			Err:                 fmt.Errorf("dial tcp 10.0.0.5:8080: %w", BadGatewayErr),
+			ExpectedStatus:      http.StatusBadGateway,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			ExpectedContent:     "bad gateway\n",
+		},
+		{
+			Name:                "should render unknown errors as internal errors",
+			Err:                 fmt.Errorf("secret database password is wrong"),
+			ExpectedStatus:      http.StatusInternalServerError,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			ExpectedContent:     "code: internal_error",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+			request.Header.Set("Accept", test.Accept)
+			response := httptest.NewRecorder()
+
+			// act
+			err := WriteError(response, request, test.Err)
+
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+			assert.Equal(t, test.ExpectedContentType, response.Header().Get("Content-Type"))
+			assert.Contains(t, response.Body.String(), test.ExpectedContent)
+			assert.NotContains(t, response.Body.String(), "10.0.0.5")
+			assert.NotContains(t, response.Body.String(), "secret")
+		})
+	}
+
+	t.Run("should render request id and problem members", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+		request = request.WithContext(WithRequestId(request.Context(), "request-1"))
+		response := httptest.NewRecorder()
+		renderer := &ProblemJsonErrorRenderer{}
+
+		// act
+		err := renderer.Render(response, request, CreateProxyError(request, ServiceUnavailableErr))
+
+		// assert
+		require.NoError(t, err)
+		problem := make(map[string]any)
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &problem))
+		assert.Equal(t, "about:blank", problem["type"])
+		assert.Equal(t, "Service Unavailable", problem["title"])
+		assert.Equal(t, float64(http.StatusServiceUnavailable), problem["status"])
+		assert.Equal(t, "/simple-query", problem["instance"])
+		assert.Equal(t, "service_unavailable", problem["code"])
+		assert.Equal(t, "request-1", problem["request_id"])
+	})
+
+	t.Run("should use renderer from request context", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		renderer, err := CreateHtmlErrorRenderer("<p>{{.Code}} {{.RequestId}}</p>")
+		require.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+		request = request.WithContext(WithRequestId(WithErrorRenderer(request.Context(), renderer), "request-1"))
+		response := httptest.NewRecorder()
+
+		// act
+		err = WriteError(response, request, ServiceUnavailableErr)
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, "<p>service_unavailable request-1</p>", response.Body.String())
+	})
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type HeaderOperation int
+
+const (
+	SetHeaderOperation HeaderOperation = iota
+	AddHeaderOperation
+	RemoveHeaderOperation
+	RenameHeaderOperation
+)
+
+// HeaderRule sets, adds, removes or renames a header, set and add values are request templates
+type HeaderRule struct {
+	operation HeaderOperation
+	name      string
+	newName   string
+	value     *requestTemplate
+}
+
+func CreateSetHeaderRule(name string, valueTemplate string) (*HeaderRule, error) {
+	return createValuedHeaderRule(SetHeaderOperation, name, valueTemplate)
+}
+
+func CreateAddHeaderRule(name string, valueTemplate string) (*HeaderRule, error) {
+	return createValuedHeaderRule(AddHeaderOperation, name, valueTemplate)
+}
+
+func CreateRemoveHeaderRule(name string) *HeaderRule {
+	return &HeaderRule{
+		operation: RemoveHeaderOperation,
+		name:      http.CanonicalHeaderKey(name),
+	}
+}
+
+func CreateRenameHeaderRule(name string, newName string) *HeaderRule {
+	return &HeaderRule{
+		operation: RenameHeaderOperation,
+		name:      http.CanonicalHeaderKey(name),
+		newName:   http.CanonicalHeaderKey(newName),
+	}
+}
+
+func createValuedHeaderRule(operation HeaderOperation, name string, valueTemplate string) (*HeaderRule, error) {
+	value, err := parseRequestTemplate(valueTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of header %q: %w", name, err)
+	}
+
+	return &HeaderRule{
+		operation: operation,
+		name:      http.CanonicalHeaderKey(name),
+		value:     value,
+	}, nil
+}
+
+// DefaultResponseHeaderRules removes the headers disclosing the upstream technologies
+func DefaultResponseHeaderRules() []*HeaderRule {
+	return []*HeaderRule{
+		CreateRemoveHeaderRule("Server"),
+		CreateRemoveHeaderRule("X-Powered-By"),
+		CreateRemoveHeaderRule("X-Aspnet-Version"),
+		CreateRemoveHeaderRule("X-Aspnetmvc-Version"),
+	}
+}
+
+// ApplyHeaderRules applies the rules in order, template variables are resolved from the request r
+func ApplyHeaderRules(header http.Header, r *http.Request, rules []*HeaderRule) {
+	for _, rule := range rules {
+		switch rule.operation {
+		case SetHeaderOperation:
+			header.Set(rule.name, rule.value.render(r))
+		case AddHeaderOperation:
+			header.Add(rule.name, rule.value.render(r))
+		case RemoveHeaderOperation:
+			header.Del(rule.name)
+		case RenameHeaderOperation:
+			values, ok := header[rule.name]
+			if !ok {
+				continue
+			}
+
+			header.Del(rule.name)
+			header[rule.newName] = append(header[rule.newName], values...)
+		}
+	}
+}
+
+func CreateRequestHeaderRulesMiddleware(rules ...*HeaderRule) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rewrittenRequest := r.Clone(r.Context())
+			ApplyHeaderRules(rewrittenRequest.Header, r, rules)
+			next.ServeHTTP(w, rewrittenRequest)
+		})
+	}
+}
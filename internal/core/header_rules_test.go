@@ -0,0 +1,109 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplyHeaderRules(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Rules          []*HeaderRule
+		Header         http.Header
+		ExpectedHeader http.Header
+	}{
+		{
+			Name:           "should set header",
+			Rules:          []*HeaderRule{createTestSetHeaderRule("x-tenant", "acme")},
+			Header:         http.Header{"X-Tenant": {"other", "values"}},
+			ExpectedHeader: http.Header{"X-Tenant": {"acme"}},
+		},
+		{
+			Name:           "should add header",
+			Rules:          []*HeaderRule{createTestAddHeaderRule("Via", "goprx")},
+			Header:         http.Header{"Via": {"1.1 cdn"}},
+			ExpectedHeader: http.Header{"Via": {"1.1 cdn", "goprx"}},
+		},
+		{
+			Name:           "should remove header",
+			Rules:          []*HeaderRule{CreateRemoveHeaderRule("x-debug")},
+			Header:         http.Header{"X-Debug": {"1"}, "Accept": {"*/*"}},
+			ExpectedHeader: http.Header{"Accept": {"*/*"}},
+		},
+		{
+			Name:           "should rename header keeping its values",
+			Rules:          []*HeaderRule{CreateRenameHeaderRule("X-User", "X-Consumer")},
+			Header:         http.Header{"X-User": {"a", "b"}},
+			ExpectedHeader: http.Header{"X-Consumer": {"a", "b"}},
+		},
+		{
+			Name:           "should ignore rename of missing header",
+			Rules:          []*HeaderRule{CreateRenameHeaderRule("X-User", "X-Consumer")},
+			Header:         http.Header{},
+			ExpectedHeader: http.Header{},
+		},
+		{
+			Name:           "should apply rules in order",
+			Rules:          []*HeaderRule{createTestSetHeaderRule("X-A", "1"), CreateRenameHeaderRule("X-A", "X-B"), CreateRemoveHeaderRule("X-A")},
+			Header:         http.Header{},
+			ExpectedHeader: http.Header{"X-B": {"1"}},
+		},
+		{
+			Name:           "should remove upstream technologies headers by default",
+			Rules:          DefaultResponseHeaderRules(),
+			Header:         http.Header{"Server": {"nginx"}, "X-Powered-By": {"php"}, "X-Aspnet-Version": {"4"}, "X-Aspnetmvc-Version": {"5"}, "Content-Type": {"text/plain"}},
+			ExpectedHeader: http.Header{"Content-Type": {"text/plain"}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// act
+			ApplyHeaderRules(test.Header, httptest.NewRequest(http.MethodGet, "http://localhost/", nil), test.Rules)
+
+			// assert
+			assert.Equal(t, test.ExpectedHeader, test.Header)
+		})
+	}
+}
+
+func TestHeaderRuleTemplate(t *testing.T) {
+	// arrange
+	rule := createTestSetHeaderRule("X-Context", "ip={client_ip} id={request_id} route={route} user={param.id} missing={param.other} {{literal}}")
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/users/42", nil)
+	request.RemoteAddr = "203.0.113.7:1234"
+	ctx := WithRequestId(request.Context(), "request-1")
+	ctx = WithRouteName(ctx, "users")
+	ctx = WithPathParams(ctx, map[string]string{"id": "42"})
+	header := http.Header{}
+
+	// act
+	ApplyHeaderRules(header, request.WithContext(ctx), []*HeaderRule{rule})
+
+	// assert
+	assert.Equal(t, "ip=203.0.113.7 id=request-1 route=users user=42 missing= {literal}", header.Get("X-Context"))
+}
+
+func TestHeaderRuleCreation(t *testing.T) {
+	for _, template := range []string{"{unknown}", "{client_ip", "value}", "{param.}"} {
+		_, err := CreateSetHeaderRule("X-Value", template)
+		assert.Error(t, err, template)
+	}
+
+	_, err := CreateAddHeaderRule("X-Value", "{request_id}")
+	require.NoError(t, err)
+}
+
+func createTestSetHeaderRule(name string, valueTemplate string) *HeaderRule {
+	rule, _ := CreateSetHeaderRule(name, valueTemplate)
+	return rule
+}
+
+func createTestAddHeaderRule(name string, valueTemplate string) *HeaderRule {
+	rule, _ := CreateAddHeaderRule(name, valueTemplate)
+	return rule
+}
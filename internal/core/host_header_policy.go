@@ -0,0 +1,51 @@
+package core
+
+import (
+	"net/http"
+)
+
+type hostHeaderMode int
+
+const (
+	upstreamHostHeaderMode hostHeaderMode = iota
+	preserveHostHeaderMode
+	fixedHostHeaderMode
+)
+
+// HostHeaderPolicy decides the Host header of the requests forwarded upstream, the upstream address is used by default
+type HostHeaderPolicy struct {
+	mode hostHeaderMode
+	host string
+}
+
+func CreateUpstreamHostHeaderPolicy() *HostHeaderPolicy {
+	return &HostHeaderPolicy{mode: upstreamHostHeaderMode}
+}
+
+func CreatePreserveHostHeaderPolicy() *HostHeaderPolicy {
+	return &HostHeaderPolicy{mode: preserveHostHeaderMode}
+}
+
+func CreateFixedHostHeaderPolicy(host string) *HostHeaderPolicy {
+	return &HostHeaderPolicy{mode: fixedHostHeaderMode, host: host}
+}
+
+// hostHeader returns the Host header to send upstream, empty when the upstream address must be used
+func (p *HostHeaderPolicy) hostHeader(req *http.Request) string {
+	switch p.mode {
+	case preserveHostHeaderMode:
+		return req.Host
+	case fixedHostHeaderMode:
+		return p.host
+	}
+
+	return ""
+}
+
+func CreateHostHeaderPolicyMiddleware(policy *HostHeaderPolicy) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithHostHeaderPolicy(r.Context(), policy)))
+		})
+	}
+}
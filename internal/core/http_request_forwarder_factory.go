@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 )
 
 type HttpRequestForwarderFactory struct {
@@ -18,7 +19,7 @@ func CreateHttpRequestForwarderFactory(logger *slog.Logger) *HttpRequestForwarde
 }
 
 func (r *HttpRequestForwarderFactory) CreateForwardedRequestTo(req *http.Request, host string) (*http.Request, error) {
-	newRequestURL := fmt.Sprintf("http://%s%s", host, req.URL.Path)
+	newRequestURL := fmt.Sprintf("http://%s%s", host, req.URL.EscapedPath())
 
 	if len(req.URL.RawQuery) > 0 {
 		newRequestURL = fmt.Sprintf("%s?%s", newRequestURL, req.URL.RawQuery)
@@ -33,17 +34,21 @@ func (r *HttpRequestForwarderFactory) CreateForwardedRequestTo(req *http.Request
 	r.forwardRequestHeaders(req, newRequest)
 	r.forwardRequestCookies(req, newRequest)
 
+	if hostHeader := HostHeaderPolicyFromContext(req.Context()).hostHeader(req); hostHeader != "" {
+		newRequest.Host = hostHeader
+	}
+
 	return newRequest, nil
 }
 
 func (r *HttpRequestForwarderFactory) forwardRequestHeaders(req *http.Request, newRequest *http.Request) {
-	for headerName := range req.Header {
+	for headerName, headerValues := range req.Header {
 		if headerName == "Cookie" {
 			continue
 		}
 
 		r.logger.Log(req.Context(), slog.LevelDebug, "adding header from original request", slog.String("header_name", headerName))
-		newRequest.Header.Set(headerName, req.Header.Get(headerName))
+		newRequest.Header[headerName] = slices.Clone(headerValues)
 	}
 
 	r.logger.Log(req.Context(), slog.LevelDebug, "adding X-Forwarded-* headers to the request",
@@ -45,6 +45,22 @@ func TestRequestFactory(t *testing.T) {
 		assert.Equal(t, expectedPath, newRequest.URL.Path)
 	})
 
+	t.Run("should forward request with encoded slashes preserved", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		factory := CreateHttpRequestForwarderFactory(slog.Default())
+		originalRequest := httptest.NewRequest(http.MethodGet, "http://test.com/files/a%2Fb", nil)
+
+		// act
+		newRequest, err := factory.CreateForwardedRequestTo(originalRequest, "127.0.0.1:8080")
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, "/files/a/b", newRequest.URL.Path)
+		assert.Equal(t, "/files/a%2Fb", newRequest.URL.EscapedPath())
+	})
+
 	t.Run("should forward request with original query params", func(t *testing.T) {
 		t.Parallel()
 
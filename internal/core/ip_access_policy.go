@@ -0,0 +1,55 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+)
+
+var ForbiddenErr = errors.New("access to the application is forbidden")
+
+// IpAccessPolicy denies the client addresses contained in the deny list,
+// when the allow list is not empty only the addresses it contains are allowed
+type IpAccessPolicy struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func CreateIpAccessPolicy(allow []string, deny []string) (*IpAccessPolicy, error) {
+	allowPrefixes, err := ParsePrefixes(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+
+	denyPrefixes, err := ParsePrefixes(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+
+	return &IpAccessPolicy{
+		allow: allowPrefixes,
+		deny:  denyPrefixes,
+	}, nil
+}
+
+func (p *IpAccessPolicy) IsAllowed(addr netip.Addr) bool {
+	if !addr.IsValid() || containsAddr(p.deny, addr) {
+		return false
+	}
+
+	return len(p.allow) == 0 || containsAddr(p.allow, addr)
+}
+
+func CreateIpAccessPolicyMiddleware(policy *IpAccessPolicy) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !policy.IsAllowed(ClientIp(r)) {
+				_ = WriteError(w, r, ForbiddenErr)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type JwksConfig struct {
+	// RefreshIntervalInMs is the lifetime of the cached keys
+	RefreshIntervalInMs time.Duration
+	// MinRefreshIntervalInMs throttles the refreshes triggered by tokens signed with an unknown key
+	MinRefreshIntervalInMs time.Duration
+	TimeoutInMs            time.Duration
+}
+
+func CreateJwksConfig() *JwksConfig {
+	return &JwksConfig{
+		RefreshIntervalInMs:    3600000,
+		MinRefreshIntervalInMs: 60000,
+		TimeoutInMs:            5000,
+	}
+}
+
+// JwksJwtVerifier verifies the tokens with the keys of a JSON Web Key Set, the keys are fetched on first use,
+// cached and fetched again when they expire or when a token is signed with an unknown key, as after a key rotation
+type JwksJwtVerifier struct {
+	logger *slog.Logger
+	url    string
+	config *JwksConfig
+	client *http.Client
+
+	fetchMutex sync.Mutex
+	mutex      sync.RWMutex
+	keys       []*jsonWebKey
+	fetchedAt  time.Time
+}
+
+type jsonWebKey struct {
+	id        string
+	algorithm string
+	key       crypto.PublicKey
+}
+
+func CreateJwksJwtVerifier(url string, config *JwksConfig, logger *slog.Logger) *JwksJwtVerifier {
+	return &JwksJwtVerifier{
+		logger: logger,
+		url:    url,
+		config: config,
+		client: &http.Client{Timeout: config.TimeoutInMs * time.Millisecond},
+	}
+}
+
+func (v *JwksJwtVerifier) Verify(token *Jwt) error {
+	keyId, _ := token.Header["kid"].(string)
+
+	keys, fetchedAt := v.cachedKeys()
+	if time.Since(fetchedAt) >= v.config.RefreshIntervalInMs*time.Millisecond {
+		keys, fetchedAt = v.refresh(fetchedAt)
+	}
+
+	candidates := matchingJsonWebKeys(keys, keyId, token.Algorithm())
+	if len(candidates) == 0 && time.Since(fetchedAt) >= v.config.MinRefreshIntervalInMs*time.Millisecond {
+		keys, _ = v.refresh(fetchedAt)
+		candidates = matchingJsonWebKeys(keys, keyId, token.Algorithm())
+	}
+
+	return verifyJwtSignatureWithKeys(token, candidates)
+}
+
+func (v *JwksJwtVerifier) cachedKeys() ([]*jsonWebKey, time.Time) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.keys, v.fetchedAt
+}
+
+// refresh fetches the keys unless another request fetched them since staleFetchedAt,
+// the previous keys are kept when the fetch fails and a new attempt waits for the refresh interval
+func (v *JwksJwtVerifier) refresh(staleFetchedAt time.Time) ([]*jsonWebKey, time.Time) {
+	v.fetchMutex.Lock()
+	defer v.fetchMutex.Unlock()
+
+	keys, fetchedAt := v.cachedKeys()
+	if !fetchedAt.Equal(staleFetchedAt) {
+		return keys, fetchedAt
+	}
+
+	fetchedKeys, err := v.fetch()
+	fetchedAt = time.Now()
+	if err != nil {
+		v.logger.Log(context.Background(), slog.LevelError, "cannot fetch json web key set, previous keys kept", slog.String("url", v.url), slog.Any("error", err))
+	} else {
+		keys = fetchedKeys
+	}
+
+	v.mutex.Lock()
+	v.keys = keys
+	v.fetchedAt = fetchedAt
+	v.mutex.Unlock()
+
+	return keys, fetchedAt
+}
+
+func (v *JwksJwtVerifier) fetch() ([]*jsonWebKey, error) {
+	resp, err := v.client.Get(v.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJsonWebKeySet(content)
+}
+
+func matchingJsonWebKeys(keys []*jsonWebKey, keyId string, algorithm string) []crypto.PublicKey {
+	candidates := make([]crypto.PublicKey, 0, 1)
+	for _, key := range keys {
+		if keyId != "" && key.id != keyId {
+			continue
+		}
+
+		if key.algorithm != "" && key.algorithm != algorithm {
+			continue
+		}
+
+		candidates = append(candidates, key.key)
+	}
+
+	return candidates
+}
+
+// parseJsonWebKeySet reads the RSA, P-256 EC and Ed25519 OKP signature keys, the other keys are ignored
+func parseJsonWebKeySet(content []byte) ([]*jsonWebKey, error) {
+	var keySet struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(content, &keySet); err != nil {
+		return nil, fmt.Errorf("invalid json web key set: %w", err)
+	}
+
+	keys := make([]*jsonWebKey, 0, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch {
+		case jwk.Kty == "RSA":
+			key, err = parseRsaJsonWebKey(jwk.N, jwk.E)
+		case jwk.Kty == "EC" && jwk.Crv == "P-256":
+			key, err = parseEcJsonWebKey(jwk.X, jwk.Y)
+		case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+			key, err = parseEd25519JsonWebKey(jwk.X)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid json web key %q: %w", jwk.Kid, err)
+		}
+
+		keys = append(keys, &jsonWebKey{id: jwk.Kid, algorithm: jwk.Alg, key: key})
+	}
+
+	return keys, nil
+}
+
+func parseRsaJsonWebKey(modulus string, exponent string) (*rsa.PublicKey, error) {
+	n, err := decodeJsonWebKeyInt(modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := decodeJsonWebKeyInt(exponent)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid rsa exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseEcJsonWebKey(x string, y string) (*ecdsa.PublicKey, error) {
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := elliptic.P256()
+	publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xBytes), Y: new(big.Int).SetBytes(yBytes)}
+	if len(xBytes) != 32 || len(yBytes) != 32 || !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("invalid P-256 point")
+	}
+
+	return publicKey, nil
+}
+
+func parseEd25519JsonWebKey(x string) (ed25519.PublicKey, error) {
+	key, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 key size")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+func decodeJsonWebKeyInt(value string) (*big.Int, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == 0 {
+		return nil, errors.New("empty integer")
+	}
+
+	return new(big.Int).SetBytes(content), nil
+}
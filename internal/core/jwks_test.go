@@ -0,0 +1,160 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestJwksJwtVerifier(t *testing.T) {
+	// arrange
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublicKey, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	jwks := createTestJwks(t, map[string]any{
+		"rsa": rsaJsonWebKey(&rsaKey.PublicKey),
+		"ec":  ecJsonWebKey(&ecKey.PublicKey),
+		"ed":  map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(edPublicKey)},
+	})
+	verifier := CreateJwksJwtVerifier(jwks.URL, CreateJwksConfig(), createTestErrorLogger())
+
+	testCases := map[string]struct {
+		Token       string
+		ExpectError bool
+	}{
+		"should verify RS256 token":                {Token: createTestSignedJwt("RS256", "rsa", map[string]any{"sub": "user"}, rsaSigner(rsaKey))},
+		"should verify ES256 token":                {Token: createTestSignedJwt("ES256", "ec", map[string]any{"sub": "user"}, ecSigner(ecKey))},
+		"should verify EdDSA token":                {Token: createTestSignedJwt("EdDSA", "ed", map[string]any{"sub": "user"}, edSigner(edKey))},
+		"should reject token of another key id":    {Token: createTestSignedJwt("RS256", "ec", map[string]any{"sub": "user"}, rsaSigner(rsaKey)), ExpectError: true},
+		"should reject token of unknown key id":    {Token: createTestSignedJwt("RS256", "unknown", map[string]any{"sub": "user"}, rsaSigner(rsaKey)), ExpectError: true},
+		"should reject token of another algorithm": {Token: createTestSignedJwt("EdDSA", "rsa", map[string]any{"sub": "user"}, edSigner(edKey)), ExpectError: true},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			token, err := ParseJwt(test.Token)
+			require.NoError(t, err)
+
+			// act
+			err = verifier.Verify(token)
+
+			// assert
+			assert.Equal(t, test.ExpectError, err != nil, err)
+		})
+	}
+
+	assert.Equal(t, int32(1), jwks.requests.Load())
+}
+
+func TestJwksJwtVerifierShouldRefreshKeysAfterRotation(t *testing.T) {
+	// arrange
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwks := createTestJwks(t, map[string]any{"old": rsaJsonWebKey(&oldKey.PublicKey)})
+	config := CreateJwksConfig()
+	config.MinRefreshIntervalInMs = 0
+	verifier := CreateJwksJwtVerifier(jwks.URL, config, createTestErrorLogger())
+
+	oldToken, _ := ParseJwt(createTestSignedJwt("RS256", "old", map[string]any{"sub": "user"}, rsaSigner(oldKey)))
+	require.NoError(t, verifier.Verify(oldToken))
+
+	jwks.setKeys(map[string]any{"new": rsaJsonWebKey(&newKey.PublicKey)})
+	newToken, _ := ParseJwt(createTestSignedJwt("RS256", "new", map[string]any{"sub": "user"}, rsaSigner(newKey)))
+
+	// act
+	err := verifier.Verify(newToken)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), jwks.requests.Load())
+}
+
+func TestJwksJwtVerifierShouldThrottleRefreshOfUnknownKeys(t *testing.T) {
+	// arrange
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := createTestJwks(t, map[string]any{"known": rsaJsonWebKey(&key.PublicKey)})
+	verifier := CreateJwksJwtVerifier(jwks.URL, CreateJwksConfig(), createTestErrorLogger())
+
+	// act
+	for range 3 {
+		token, _ := ParseJwt(createTestSignedJwt("RS256", "unknown", map[string]any{"sub": "user"}, rsaSigner(key)))
+		assert.Error(t, verifier.Verify(token))
+	}
+
+	// assert
+	assert.Equal(t, int32(1), jwks.requests.Load())
+}
+
+type testJwks struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	keys     map[string]any
+	requests atomic.Int32
+}
+
+func createTestJwks(t *testing.T, keys map[string]any) *testJwks {
+	jwks := &testJwks{keys: keys}
+	jwks.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwks.requests.Add(1)
+
+		jwks.mutex.Lock()
+		defer jwks.mutex.Unlock()
+
+		keySet := make([]any, 0, len(jwks.keys))
+		for keyId, key := range jwks.keys {
+			jwk := key.(map[string]any)
+			jwk["kid"] = keyId
+			keySet = append(keySet, jwk)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keySet})
+	}))
+	t.Cleanup(jwks.Close)
+
+	return jwks
+}
+
+func (j *testJwks) setKeys(keys map[string]any) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.keys = keys
+}
+
+func rsaJsonWebKey(key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJsonWebKey(key *ecdsa.PublicKey) map[string]any {
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	return map[string]any{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(x),
+		"y":   base64.RawURLEncoding.EncodeToString(y),
+	}
+}
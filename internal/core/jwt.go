@@ -0,0 +1,345 @@
+package core
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var InvalidJwtErr = errors.New("invalid jwt")
+
+type Jwt struct {
+	Header       map[string]any
+	Claims       map[string]any
+	signingInput string
+	signature    []byte
+}
+
+// ParseJwt decodes a compact serialized JWT without verifying its signature, numeric claims are kept as json.Number
+func ParseJwt(token string) (*Jwt, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", InvalidJwtErr, len(parts))
+	}
+
+	header, err := decodeJwtPart(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %w", InvalidJwtErr, err)
+	}
+
+	claims, err := decodeJwtPart(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid claims: %w", InvalidJwtErr, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature encoding: %w", InvalidJwtErr, err)
+	}
+
+	return &Jwt{
+		Header:       header,
+		Claims:       claims,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}, nil
+}
+
+func decodeJwtPart(part string) (map[string]any, error) {
+	content, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	values := make(map[string]any)
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// BearerToken returns the token of the Authorization header using the bearer scheme
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func (t *Jwt) Algorithm() string {
+	algorithm, _ := t.Header["alg"].(string)
+	return algorithm
+}
+
+// ClaimValues returns the claim as strings, every element is returned when the claim is an array
+func (t *Jwt) ClaimValues(name string) ([]string, bool) {
+	claim, ok := t.Claims[name]
+	if !ok {
+		return nil, false
+	}
+
+	elements, isArray := claim.([]any)
+	if !isArray {
+		elements = []any{claim}
+	}
+
+	values := make([]string, 0, len(elements))
+	for _, element := range elements {
+		switch typedElement := element.(type) {
+		case string:
+			values = append(values, typedElement)
+		case json.Number:
+			values = append(values, typedElement.String())
+		case bool:
+			values = append(values, strconv.FormatBool(typedElement))
+		}
+	}
+
+	return values, true
+}
+
+// ValidateTime checks the exp and nbf claims when they are present
+func (t *Jwt) ValidateTime(now time.Time, skew time.Duration) error {
+	expiresAt, ok, err := t.timeClaim("exp")
+	if err != nil {
+		return err
+	}
+
+	if ok && !now.Add(-skew).Before(expiresAt) {
+		return fmt.Errorf("%w: token expired", InvalidJwtErr)
+	}
+
+	notBefore, ok, err := t.timeClaim("nbf")
+	if err != nil {
+		return err
+	}
+
+	if ok && now.Add(skew).Before(notBefore) {
+		return fmt.Errorf("%w: token not valid yet", InvalidJwtErr)
+	}
+
+	return nil
+}
+
+// maxJwtTimeClaimSeconds is the last second of the year 9999, the time claims cannot be later
+const maxJwtTimeClaimSeconds = 253402300799
+
+// timeClaim returns false when the claim is missing and InvalidJwtErr when it is not a number of seconds
+// between the epoch and maxJwtTimeClaimSeconds
+func (t *Jwt) timeClaim(name string) (time.Time, bool, error) {
+	value, ok := t.Claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %q claim is not a number", InvalidJwtErr, name)
+	}
+
+	seconds, err := number.Float64()
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, false, fmt.Errorf("%w: %q claim is not a number", InvalidJwtErr, name)
+	}
+
+	if seconds < 0 || seconds > maxJwtTimeClaimSeconds {
+		return time.Time{}, false, fmt.Errorf("%w: %q claim is out of range", InvalidJwtErr, name)
+	}
+
+	wholeSeconds := math.Floor(seconds)
+	return time.Unix(int64(wholeSeconds), int64((seconds-wholeSeconds)*1e9)), true, nil
+}
+
+type JwtVerifier interface {
+	Verify(token *Jwt) error
+}
+
+// jwtKeyMismatchErr is returned when the key cannot verify the algorithm of the token, another key may
+var jwtKeyMismatchErr = fmt.Errorf("%w: key does not match the token algorithm", InvalidJwtErr)
+
+// verifyJwtSignature checks the signature with the key of the algorithm of the token, a []byte for HS256,
+// an *rsa.PublicKey for RS256, a P-256 *ecdsa.PublicKey for ES256 and an ed25519.PublicKey for EdDSA,
+// so a public key can never be used as an HMAC secret
+func verifyJwtSignature(token *Jwt, key any) error {
+	digest := sha256.Sum256([]byte(token.signingInput))
+	valid := false
+
+	switch token.Algorithm() {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return jwtKeyMismatchErr
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(token.signingInput))
+		valid = hmac.Equal(mac.Sum(nil), token.signature)
+	case "RS256":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return jwtKeyMismatchErr
+		}
+
+		valid = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], token.signature) == nil
+	case "ES256":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve != elliptic.P256() {
+			return jwtKeyMismatchErr
+		}
+
+		// the signature is the concatenation of r and s, not their ASN.1 encoding
+		if len(token.signature) == 64 {
+			r := new(big.Int).SetBytes(token.signature[:32])
+			s := new(big.Int).SetBytes(token.signature[32:])
+			valid = ecdsa.Verify(publicKey, digest[:], r, s)
+		}
+	case "EdDSA":
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return jwtKeyMismatchErr
+		}
+
+		valid = ed25519.Verify(publicKey, []byte(token.signingInput), token.signature)
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", InvalidJwtErr, token.Algorithm())
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: signature mismatch", InvalidJwtErr)
+	}
+
+	return nil
+}
+
+// verifyJwtSignatureWithKeys accepts the token verified by any of the keys
+func verifyJwtSignatureWithKeys(token *Jwt, keys []crypto.PublicKey) error {
+	err := fmt.Errorf("%w: no key for algorithm %q", InvalidJwtErr, token.Algorithm())
+	for _, key := range keys {
+		keyErr := verifyJwtSignature(token, key)
+		if keyErr == nil {
+			return nil
+		}
+
+		if !errors.Is(keyErr, jwtKeyMismatchErr) {
+			err = keyErr
+		}
+	}
+
+	return err
+}
+
+type HmacJwtVerifier struct {
+	secret []byte
+}
+
+// CreateHmacJwtVerifier verifies HS256 signatures with the shared secret
+func CreateHmacJwtVerifier(secret []byte) *HmacJwtVerifier {
+	return &HmacJwtVerifier{
+		secret: secret,
+	}
+}
+
+func (h *HmacJwtVerifier) Verify(token *Jwt) error {
+	return verifyJwtSignature(token, h.secret)
+}
+
+type PublicKeyJwtVerifier struct {
+	keys []crypto.PublicKey
+}
+
+// CreatePublicKeyJwtVerifier verifies RS256, ES256 and EdDSA signatures with any of the keys
+func CreatePublicKeyJwtVerifier(keys ...crypto.PublicKey) *PublicKeyJwtVerifier {
+	return &PublicKeyJwtVerifier{
+		keys: keys,
+	}
+}
+
+// CreatePemJwtVerifier loads the public keys, or the certificates, of the PEM files
+func CreatePemJwtVerifier(paths ...string) (*PublicKeyJwtVerifier, error) {
+	keys := make([]crypto.PublicKey, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read jwt key file: %w", err)
+		}
+
+		fileKeys, err := parsePemPublicKeys(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt key file %q: %w", path, err)
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	return CreatePublicKeyJwtVerifier(keys...), nil
+}
+
+func parsePemPublicKeys(content []byte) ([]crypto.PublicKey, error) {
+	keys := make([]crypto.PublicKey, 0)
+	for {
+		block, rest := pem.Decode(content)
+		if block == nil {
+			break
+		}
+
+		content = rest
+
+		var key crypto.PublicKey
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var certificate *x509.Certificate
+			certificate, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = certificate.PublicKey
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no public key found")
+	}
+
+	return keys, nil
+}
+
+func (v *PublicKeyJwtVerifier) Verify(token *Jwt) error {
+	return verifyJwtSignatureWithKeys(token, v.keys)
+}
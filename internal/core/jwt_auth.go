@@ -0,0 +1,120 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type JwtAuthenticationConfig struct {
+	// Issuer and Audiences are not checked when empty, the token must be issued for one of the audiences otherwise
+	Issuer        string
+	Audiences     []string
+	ClockSkewInMs time.Duration
+	// RequiredClaims must be present, the exp and nbf claims are rejected when they are not a number of seconds
+	RequiredClaims []string
+	// ConsumerClaim holds the identity of the consumer
+	ConsumerClaim string
+	// ClaimHeaders forwards the claims upstream in the headers, keyed by claim name, array claims are joined with commas
+	ClaimHeaders map[string]string
+}
+
+func CreateJwtAuthenticationConfig() *JwtAuthenticationConfig {
+	return &JwtAuthenticationConfig{
+		ClockSkewInMs:  60000,
+		RequiredClaims: []string{"exp"},
+		ConsumerClaim:  "sub",
+		ClaimHeaders:   map[string]string{},
+	}
+}
+
+// JwtAuthenticator accepts the bearer tokens verified by the verifier and satisfying the claims of the config
+type JwtAuthenticator struct {
+	realm    string
+	verifier JwtVerifier
+	config   *JwtAuthenticationConfig
+	now      func() time.Time
+}
+
+func CreateJwtAuthenticator(realm string, verifier JwtVerifier, config *JwtAuthenticationConfig) *JwtAuthenticator {
+	return &JwtAuthenticator{
+		realm:    realm,
+		verifier: verifier,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+func (a *JwtAuthenticator) Authenticate(r *http.Request) (*Consumer, error) {
+	rawToken, ok := BearerToken(r)
+	if !ok {
+		return nil, UnauthorizedErr
+	}
+
+	token, err := ParseJwt(rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", UnauthorizedErr, err)
+	}
+
+	if err := a.validate(token); err != nil {
+		return nil, fmt.Errorf("%w: %w", UnauthorizedErr, err)
+	}
+
+	consumerIds, _ := token.ClaimValues(a.config.ConsumerClaim)
+	if len(consumerIds) == 0 || consumerIds[0] == "" {
+		return nil, fmt.Errorf("%w: token has no %q claim", UnauthorizedErr, a.config.ConsumerClaim)
+	}
+
+	headers := make(http.Header)
+	for claim, header := range a.config.ClaimHeaders {
+		if values, ok := token.ClaimValues(claim); ok && len(values) > 0 {
+			headers.Set(header, strings.Join(values, ","))
+		}
+	}
+
+	return &Consumer{Id: consumerIds[0], Headers: headers}, nil
+}
+
+func (a *JwtAuthenticator) validate(token *Jwt) error {
+	if err := a.verifier.Verify(token); err != nil {
+		return err
+	}
+
+	if err := token.ValidateTime(a.now(), a.config.ClockSkewInMs*time.Millisecond); err != nil {
+		return err
+	}
+
+	for _, claim := range a.config.RequiredClaims {
+		if _, ok := token.Claims[claim]; !ok {
+			return fmt.Errorf("%w: missing %q claim", InvalidJwtErr, claim)
+		}
+	}
+
+	if a.config.Issuer != "" {
+		if issuer, _ := token.Claims["iss"].(string); issuer != a.config.Issuer {
+			return fmt.Errorf("%w: unexpected issuer %q", InvalidJwtErr, issuer)
+		}
+	}
+
+	if len(a.config.Audiences) > 0 {
+		audiences, _ := token.ClaimValues("aud")
+		if !slices.ContainsFunc(audiences, func(audience string) bool { return slices.Contains(a.config.Audiences, audience) }) {
+			return fmt.Errorf("%w: token not issued for the audience", InvalidJwtErr)
+		}
+	}
+
+	return nil
+}
+
+func (a *JwtAuthenticator) StripCredential(r *http.Request) {
+	r.Header.Del("Authorization")
+	for _, header := range a.config.ClaimHeaders {
+		r.Header.Del(header)
+	}
+}
+
+func (a *JwtAuthenticator) Challenge() string {
+	return fmt.Sprintf("Bearer realm=%q", a.realm)
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJwtAuthenticator(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	config := CreateJwtAuthenticationConfig()
+	config.Issuer = "https://issuer.example.com"
+	config.Audiences = []string{"orders-api"}
+	config.RequiredClaims = append(config.RequiredClaims, "tenant")
+	config.ClaimHeaders = map[string]string{"tenant": "X-Tenant", "roles": "X-Roles"}
+
+	validClaims := func(overrides map[string]any) map[string]any {
+		claims := map[string]any{
+			"sub":    "user-1",
+			"iss":    "https://issuer.example.com",
+			"aud":    []string{"billing-api", "orders-api"},
+			"exp":    now.Unix() + 60,
+			"tenant": "acme",
+			"roles":  []string{"admin", "viewer"},
+		}
+
+		for name, value := range overrides {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+
+			claims[name] = value
+		}
+
+		return claims
+	}
+
+	testCases := []struct {
+		Name            string
+		Claims          map[string]any
+		Secret          []byte
+		ExpectedHeaders map[string]string
+		ExpectError     bool
+	}{
+		{Name: "should authenticate valid token", Claims: validClaims(nil), ExpectedHeaders: map[string]string{"X-Tenant": "acme", "X-Roles": "admin,viewer"}},
+		{Name: "should accept token expired within clock skew", Claims: validClaims(map[string]any{"exp": now.Unix() - 30})},
+		{Name: "should reject expired token", Claims: validClaims(map[string]any{"exp": now.Unix() - 120}), ExpectError: true},
+		{Name: "should reject token without expiration", Claims: validClaims(map[string]any{"exp": nil}), ExpectError: true},
+		{Name: "should reject token with non numeric expiration", Claims: validClaims(map[string]any{"exp": "9999999999"}), ExpectError: true},
+		{Name: "should reject token of another issuer", Claims: validClaims(map[string]any{"iss": "https://other.example.com"}), ExpectError: true},
+		{Name: "should reject token of another audience", Claims: validClaims(map[string]any{"aud": "billing-api"}), ExpectError: true},
+		{Name: "should reject token without required claim", Claims: validClaims(map[string]any{"tenant": nil}), ExpectError: true},
+		{Name: "should reject token without consumer", Claims: validClaims(map[string]any{"sub": nil}), ExpectError: true},
+		{Name: "should reject forged token", Claims: validClaims(nil), Secret: []byte("forged"), ExpectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			authenticator := CreateJwtAuthenticator("orders", CreateHmacJwtVerifier(secret), config)
+			authenticator.now = func() time.Time { return now }
+
+			signingSecret := test.Secret
+			if signingSecret == nil {
+				signingSecret = secret
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.Header.Set("Authorization", "Bearer "+createTestHmacJwt(test.Claims, signingSecret))
+
+			// act
+			consumer, err := authenticator.Authenticate(request)
+
+			// assert
+			if test.ExpectError {
+				assert.ErrorIs(t, err, UnauthorizedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", consumer.Id)
+			for name, value := range test.ExpectedHeaders {
+				assert.Equal(t, value, consumer.Headers.Get(name))
+			}
+		})
+	}
+}
+
+func TestJwtAuthenticationMiddleware(t *testing.T) {
+	// arrange
+	secret := []byte("secret")
+	config := CreateJwtAuthenticationConfig()
+	config.ClaimHeaders = map[string]string{"tenant": "X-Tenant", "plan": "X-Plan"}
+	authenticator := CreateJwtAuthenticator("orders", CreateHmacJwtVerifier(secret), config)
+
+	var forwardedRequest *http.Request
+	handler := createTestAuthenticationMiddleware("X-Consumer", authenticator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedRequest = r
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	request.Header.Set("Authorization", "Bearer "+createTestHmacJwt(map[string]any{"sub": "user-1", "exp": time.Now().Unix() + 60, "tenant": "acme"}, secret))
+	request.Header.Set("X-Plan", "spoofed")
+	unauthorizedResponse := httptest.NewRecorder()
+
+	// act
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	handler.ServeHTTP(unauthorizedResponse, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	// assert
+	require.NotNil(t, forwardedRequest)
+	assert.Equal(t, "user-1", forwardedRequest.Header.Get("X-Consumer"))
+	assert.Equal(t, "acme", forwardedRequest.Header.Get("X-Tenant"))
+	assert.Empty(t, forwardedRequest.Header.Values("X-Plan"))
+	assert.Empty(t, forwardedRequest.Header.Get("Authorization"))
+	assert.Equal(t, http.StatusUnauthorized, unauthorizedResponse.Code)
+	assert.Equal(t, `Bearer realm="orders"`, unauthorizedResponse.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuthenticationMiddlewareShouldRemoveHeadersOfEveryAuthenticator(t *testing.T) {
+	// arrange
+	config := CreateJwtAuthenticationConfig()
+	config.ClaimHeaders = map[string]string{"tenant": "X-Tenant"}
+	jwt := CreateJwtAuthenticator("orders", CreateHmacJwtVerifier([]byte("secret")), config)
+	apiKeys := CreateApiKeyAuthenticator("orders", "X-Api-Key", "", map[string]string{"secret-key": "billing-service"})
+
+	var forwardedRequest *http.Request
+	handler := createTestAuthenticationMiddleware("X-Consumer", jwt, apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedRequest = r
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	request.Header.Set("X-Api-Key", "secret-key")
+	request.Header.Set("Authorization", "Bearer invalid")
+	request.Header.Set("X-Tenant", "spoofed")
+
+	// act
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	// assert
+	require.NotNil(t, forwardedRequest)
+	assert.Equal(t, "billing-service", forwardedRequest.Header.Get("X-Consumer"))
+	assert.Empty(t, forwardedRequest.Header.Values("X-Tenant"))
+	assert.Empty(t, forwardedRequest.Header.Values("Authorization"))
+	assert.Empty(t, forwardedRequest.Header.Values("X-Api-Key"))
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseJwt(t *testing.T) {
+	// arrange
+	rawToken := createTestHmacJwt(map[string]any{"tenant": "acme", "plan": []string{"pro", "beta"}, "seats": 42, "admin": true}, []byte("secret"))
+
+	// act
+	token, err := ParseJwt(rawToken)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "HS256", token.Algorithm())
+
+	tenant, ok := token.ClaimValues("tenant")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"acme"}, tenant)
+
+	plan, _ := token.ClaimValues("plan")
+	assert.Equal(t, []string{"pro", "beta"}, plan)
+
+	seats, _ := token.ClaimValues("seats")
+	assert.Equal(t, []string{"42"}, seats)
+
+	admin, _ := token.ClaimValues("admin")
+	assert.Equal(t, []string{"true"}, admin)
+
+	_, ok = token.ClaimValues("missing")
+	assert.False(t, ok)
+}
+
+func TestParseJwtShouldRejectMalformedTokens(t *testing.T) {
+	for _, rawToken := range []string{"", "a.b", "a.b.c.d", "!!.e30.", "e30.!!.", "e30.e30.!!"} {
+		_, err := ParseJwt(rawToken)
+		assert.ErrorIs(t, err, InvalidJwtErr, rawToken)
+	}
+}
+
+func TestHmacJwtVerifier(t *testing.T) {
+	verifier := CreateHmacJwtVerifier([]byte("secret"))
+
+	validToken, _ := ParseJwt(createTestHmacJwt(map[string]any{"sub": "user"}, []byte("secret")))
+	assert.NoError(t, verifier.Verify(validToken))
+
+	forgedToken, _ := ParseJwt(createTestHmacJwt(map[string]any{"sub": "user"}, []byte("other-secret")))
+	assert.ErrorIs(t, verifier.Verify(forgedToken), InvalidJwtErr)
+}
+
+func TestPublicKeyJwtVerifier(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublicKey, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherEdPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPublicKeyBytes := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+
+	testCases := []struct {
+		Name        string
+		Token       string
+		Keys        []crypto.PublicKey
+		ExpectError bool
+	}{
+		{Name: "should verify RS256 token", Token: createTestSignedJwt("RS256", "", map[string]any{"sub": "user"}, rsaSigner(rsaKey)), Keys: []crypto.PublicKey{&rsaKey.PublicKey}},
+		{Name: "should verify ES256 token", Token: createTestSignedJwt("ES256", "", map[string]any{"sub": "user"}, ecSigner(ecKey)), Keys: []crypto.PublicKey{&ecKey.PublicKey}},
+		{Name: "should verify EdDSA token", Token: createTestSignedJwt("EdDSA", "", map[string]any{"sub": "user"}, edSigner(edKey)), Keys: []crypto.PublicKey{otherEdPublicKey, edPublicKey}},
+		{Name: "should reject token signed with another key", Token: createTestSignedJwt("EdDSA", "", map[string]any{"sub": "user"}, edSigner(edKey)), Keys: []crypto.PublicKey{otherEdPublicKey}, ExpectError: true},
+		{Name: "should reject token of another algorithm", Token: createTestSignedJwt("ES256", "", map[string]any{"sub": "user"}, ecSigner(ecKey)), Keys: []crypto.PublicKey{&rsaKey.PublicKey}, ExpectError: true},
+		{Name: "should reject HS256 token signed with public key", Token: createTestHmacJwt(map[string]any{"sub": "user"}, rsaPublicKeyBytes), Keys: []crypto.PublicKey{&rsaKey.PublicKey}, ExpectError: true},
+		{Name: "should reject unsigned token", Token: createTestSignedJwt("none", "", map[string]any{"sub": "user"}, func([]byte) []byte { return nil }), Keys: []crypto.PublicKey{&rsaKey.PublicKey}, ExpectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			verifier := CreatePublicKeyJwtVerifier(test.Keys...)
+			token, err := ParseJwt(test.Token)
+			require.NoError(t, err)
+
+			// act
+			err = verifier.Verify(token)
+
+			// assert
+			assert.Equal(t, test.ExpectError, err != nil, err)
+		})
+	}
+}
+
+func TestCreatePemJwtVerifier(t *testing.T) {
+	// arrange
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPublicKey, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	pkixKey, _ := x509.MarshalPKIXPublicKey(edPublicKey)
+
+	path := filepath.Join(t.TempDir(), "keys.pem")
+	content := append(
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkixKey})...)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	// act
+	verifier, err := CreatePemJwtVerifier(path)
+
+	// assert
+	require.NoError(t, err)
+	rsaToken, _ := ParseJwt(createTestSignedJwt("RS256", "", map[string]any{"sub": "user"}, rsaSigner(rsaKey)))
+	edToken, _ := ParseJwt(createTestSignedJwt("EdDSA", "", map[string]any{"sub": "user"}, edSigner(edKey)))
+	assert.NoError(t, verifier.Verify(rsaToken))
+	assert.NoError(t, verifier.Verify(edToken))
+
+	_, err = CreatePemJwtVerifier(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestJwtValidateTime(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	testCases := []struct {
+		Name        string
+		Claims      map[string]any
+		Skew        time.Duration
+		ExpectError bool
+	}{
+		{"should accept token without time claims", map[string]any{}, 0, false},
+		{"should accept token not expired", map[string]any{"exp": now.Unix() + 60}, 0, false},
+		{"should reject expired token", map[string]any{"exp": now.Unix() - 60}, 0, true},
+		{"should accept expired token within skew", map[string]any{"exp": now.Unix() - 60}, 2 * time.Minute, false},
+		{"should reject token not valid yet", map[string]any{"nbf": now.Unix() + 60}, 0, true},
+		{"should accept far future expiration", map[string]any{"exp": 9999999999}, 0, false},
+		{"should accept fractional expiration", map[string]any{"exp": float64(now.Unix()) + 0.5}, 0, false},
+		{"should reject out of range expiration", map[string]any{"exp": 1e300}, 0, true},
+		{"should reject negative expiration", map[string]any{"exp": -1}, 0, true},
+		{"should reject non numeric expiration", map[string]any{"exp": "tomorrow"}, 0, true},
+		{"should reject null expiration", map[string]any{"exp": nil}, 0, true},
+		{"should reject non numeric not before", map[string]any{"nbf": "now"}, 0, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			token, err := ParseJwt(createTestHmacJwt(test.Claims, []byte("secret")))
+			require.NoError(t, err)
+
+			err = token.ValidateTime(now, test.Skew)
+			if test.ExpectError {
+				assert.ErrorIs(t, err, InvalidJwtErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func createTestHmacJwt(claims map[string]any, secret []byte) string {
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func createTestSignedJwt(algorithm string, keyId string, claims map[string]any, sign func(signingInput []byte) []byte) string {
+	header := map[string]any{"alg": algorithm, "typ": "JWT"}
+	if keyId != "" {
+		header["kid"] = keyId
+	}
+
+	encodedHeader, _ := json.Marshal(header)
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signingInput)))
+}
+
+func rsaSigner(key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		digest := sha256.Sum256(signingInput)
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return signature
+	}
+}
+
+func ecSigner(key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		digest := sha256.Sum256(signingInput)
+		r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature
+	}
+}
+
+func edSigner(key ed25519.PrivateKey) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		return ed25519.Sign(key, signingInput)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+const RequestIdHeader = "X-Request-Id"
+const maxRequestIdLength = 128
+
+type Middleware func(next http.Handler) http.Handler
+
+// ChainMiddlewares wraps the handler with the middlewares, the first one being the outermost
+func ChainMiddlewares(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+func CreateRequestIdMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(RequestIdHeader)
+			if !isValidRequestId(requestId) {
+				requestId = uuid.NewString()
+				r.Header.Set(RequestIdHeader, requestId)
+			}
+
+			w.Header().Set(RequestIdHeader, requestId)
+			next.ServeHTTP(w, r.WithContext(WithRequestId(r.Context(), requestId)))
+		})
+	}
+}
+
+func CreatePanicRecoveryMiddleware(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				logger.Log(r.Context(), slog.LevelError, "recovered from panic while handling request",
+					slog.Any("panic", recovered),
+					slog.String("request_id", RequestIdFromContext(r.Context())),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if recorder.wroteHeader {
+					return
+				}
+
+				if err := WriteError(w, r, InternalErr); err != nil {
+					logger.Log(r.Context(), slog.LevelError, "cannot write error to client", slog.Any("error", err))
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+
+	for _, char := range requestId {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.status = statusCode
+	}
+
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Write(content []byte) (int, error) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.status = http.StatusOK
+	}
+
+	return s.ResponseWriter.Write(content)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
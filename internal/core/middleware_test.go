@@ -0,0 +1,83 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	endpointUrl := "http://localhost/simple-query"
+
+	t.Run("should recover from panic with an internal error", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		handler := ChainMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("strategy exploded")
+		}), CreateRequestIdMiddleware(), CreatePanicRecoveryMiddleware(logger))
+
+		request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+		request.Header.Set(RequestIdHeader, "request-1")
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Contains(t, response.Body.String(), "code: internal_error")
+		assert.Contains(t, response.Body.String(), "request_id: request-1")
+		assert.NotContains(t, response.Body.String(), "strategy exploded")
+	})
+
+	t.Run("should not override response when panic occurs after headers were written", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		handler := ChainMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("strategy exploded")
+		}), CreatePanicRecoveryMiddleware(logger))
+
+		request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+		response := httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusAccepted, response.Code)
+		assert.Empty(t, response.Body.String())
+	})
+
+	t.Run("should generate a request id when missing or invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, requestId := range []string{"", "invalid request id"} {
+			// arrange
+			var contextRequestId string
+			handler := ChainMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextRequestId = RequestIdFromContext(r.Context())
+			}), CreateRequestIdMiddleware())
+
+			request := httptest.NewRequest(http.MethodGet, endpointUrl, nil)
+			request.Header.Set(RequestIdHeader, requestId)
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.NotEmpty(t, contextRequestId)
+			assert.NotEqual(t, requestId, contextRequestId)
+			assert.Equal(t, contextRequestId, response.Header().Get(RequestIdHeader))
+			assert.Equal(t, contextRequestId, request.Header.Get(RequestIdHeader))
+		}
+	})
+}
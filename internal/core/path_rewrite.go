@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const ForwardedPrefixHeader = "X-Forwarded-Prefix"
+
+// PathRewriteRule rewrites the escaped path of a request so encoded characters such as %2F are preserved
+type PathRewriteRule interface {
+	Rewrite(escapedPath string) string
+}
+
+type StripPrefixRewriteRule struct {
+	prefix string
+}
+
+// CreateStripPrefixRewriteRule removes the prefix from the paths starting with it on a segment boundary,
+// /service-a is stripped from /service-a/users but not from /service-abc/users, the rewritten path always starts with /
+func CreateStripPrefixRewriteRule(prefix string) (*StripPrefixRewriteRule, error) {
+	escapedPrefix, err := escapePathPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StripPrefixRewriteRule{
+		prefix: strings.TrimSuffix(escapedPrefix, "/"),
+	}, nil
+}
+
+func (s *StripPrefixRewriteRule) Rewrite(escapedPath string) string {
+	remainingPath, ok := strings.CutPrefix(escapedPath, s.prefix)
+	if !ok || (remainingPath != "" && remainingPath[0] != '/') {
+		return escapedPath
+	}
+
+	return ensureLeadingSlash(remainingPath)
+}
+
+type AddPrefixRewriteRule struct {
+	prefix string
+}
+
+func CreateAddPrefixRewriteRule(prefix string) (*AddPrefixRewriteRule, error) {
+	escapedPrefix, err := escapePathPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddPrefixRewriteRule{
+		prefix: strings.TrimSuffix(escapedPrefix, "/"),
+	}, nil
+}
+
+func (a *AddPrefixRewriteRule) Rewrite(escapedPath string) string {
+	return a.prefix + escapedPath
+}
+
+type RegexReplaceRewriteRule struct {
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// CreateRegexReplaceRewriteRule replaces the matches of the regex, the replacement can reference capture groups as $1 or ${name}
+func CreateRegexReplaceRewriteRule(pattern string, replacement string) (*RegexReplaceRewriteRule, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegexReplaceRewriteRule{
+		regex:       regex,
+		replacement: replacement,
+	}, nil
+}
+
+func (r *RegexReplaceRewriteRule) Rewrite(escapedPath string) string {
+	return ensureLeadingSlash(r.regex.ReplaceAllString(escapedPath, r.replacement))
+}
+
+// CreatePathRewriteMiddleware applies the rules in order before the request is forwarded,
+// the prefix removed from the original path is sent in the X-Forwarded-Prefix header
+func CreatePathRewriteMiddleware(rules ...PathRewriteRule) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			originalPath := r.URL.EscapedPath()
+			rewrittenPath := originalPath
+			for _, rule := range rules {
+				rewrittenPath = rule.Rewrite(rewrittenPath)
+			}
+
+			if rewrittenPath == originalPath {
+				// the prefix sent by the client is never trusted, the upstreams build their links with it
+				if len(r.Header.Values(ForwardedPrefixHeader)) > 0 {
+					r = r.Clone(r.Context())
+					r.Header.Del(ForwardedPrefixHeader)
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			path, err := url.PathUnescape(rewrittenPath)
+			if err != nil {
+				_ = WriteError(w, r, InvalidRewrittenPathErr)
+				return
+			}
+
+			ctx := r.Context()
+			prefix := removedPathPrefix(originalPath, rewrittenPath)
+			if prefix != "" {
+				ctx = WithForwardedPrefix(ctx, prefix)
+			}
+
+			rewrittenRequest := r.Clone(ctx)
+			rewrittenRequest.URL.Path = path
+			rewrittenRequest.URL.RawPath = rewrittenPath
+
+			rewrittenRequest.Header.Del(ForwardedPrefixHeader)
+			if prefix != "" {
+				rewrittenRequest.Header.Set(ForwardedPrefixHeader, prefix)
+			}
+
+			next.ServeHTTP(w, rewrittenRequest)
+		})
+	}
+}
+
+// removedPathPrefix returns the part of the original path preceding the segments kept by the rewrite
+func removedPathPrefix(originalPath string, rewrittenPath string) string {
+	common := 0
+	for common < len(originalPath) && common < len(rewrittenPath) &&
+		originalPath[len(originalPath)-1-common] == rewrittenPath[len(rewrittenPath)-1-common] {
+		common++
+	}
+
+	keptSuffix := originalPath[len(originalPath)-common:]
+	if slash := strings.IndexByte(keptSuffix, '/'); slash >= 0 {
+		keptSuffix = keptSuffix[slash:]
+	} else {
+		keptSuffix = ""
+	}
+
+	prefix := strings.TrimSuffix(originalPath[:len(originalPath)-len(keptSuffix)], "/")
+	if prefix == "" || prefix == "/" {
+		return ""
+	}
+
+	return prefix
+}
+
+func escapePathPrefix(prefix string) (string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("path prefix %q must start with /", prefix)
+	}
+
+	return (&url.URL{Path: prefix}).EscapedPath(), nil
+}
+
+func ensureLeadingSlash(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+
+	return "/" + path
+}
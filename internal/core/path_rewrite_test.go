@@ -0,0 +1,183 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathRewriteMiddleware(t *testing.T) {
+	testCases := []struct {
+		Name, Url               string
+		Rules                   []PathRewriteRule
+		ExpectedPath            string
+		ExpectedEscapedPath     string
+		ExpectedForwardedPrefix string
+	}{
+		{
+			Name:                    "should strip prefix",
+			Url:                     "http://localhost/service-a/users/42",
+			Rules:                   []PathRewriteRule{createTestStripPrefixRule("/service-a/")},
+			ExpectedPath:            "/users/42",
+			ExpectedEscapedPath:     "/users/42",
+			ExpectedForwardedPrefix: "/service-a",
+		},
+		{
+			Name:                    "should strip prefix to root",
+			Url:                     "http://localhost/service-a",
+			Rules:                   []PathRewriteRule{createTestStripPrefixRule("/service-a")},
+			ExpectedPath:            "/",
+			ExpectedEscapedPath:     "/",
+			ExpectedForwardedPrefix: "/service-a",
+		},
+		{
+			Name:                "should not rewrite path without prefix",
+			Url:                 "http://localhost/other/users",
+			Rules:               []PathRewriteRule{createTestStripPrefixRule("/service-a")},
+			ExpectedPath:        "/other/users",
+			ExpectedEscapedPath: "/other/users",
+		},
+		{
+			Name:                "should not strip prefix inside a path segment",
+			Url:                 "http://localhost/service-abc/x",
+			Rules:               []PathRewriteRule{createTestStripPrefixRule("/service-a")},
+			ExpectedPath:        "/service-abc/x",
+			ExpectedEscapedPath: "/service-abc/x",
+		},
+		{
+			Name:                "should add prefix",
+			Url:                 "http://localhost/users",
+			Rules:               []PathRewriteRule{createTestAddPrefixRule("/api/v1/")},
+			ExpectedPath:        "/api/v1/users",
+			ExpectedEscapedPath: "/api/v1/users",
+		},
+		{
+			Name:                    "should replace with capture groups",
+			Url:                     "http://localhost/v1/users/42",
+			Rules:                   []PathRewriteRule{createTestRegexReplaceRule("^/v1/users/(?P<id>[0-9]+)$", "/internal/accounts/${id}")},
+			ExpectedPath:            "/internal/accounts/42",
+			ExpectedEscapedPath:     "/internal/accounts/42",
+			ExpectedForwardedPrefix: "/v1/users",
+		},
+		{
+			Name:                    "should keep encoded slashes when stripping prefix",
+			Url:                     "http://localhost/service-a/files/a%2Fb",
+			Rules:                   []PathRewriteRule{createTestStripPrefixRule("/service-a")},
+			ExpectedPath:            "/files/a/b",
+			ExpectedEscapedPath:     "/files/a%2Fb",
+			ExpectedForwardedPrefix: "/service-a",
+		},
+		{
+			Name:                    "should keep encoded slashes in capture groups",
+			Url:                     "http://localhost/v1/files/a%2Fb",
+			Rules:                   []PathRewriteRule{createTestRegexReplaceRule("^/v1/files/(.*)$", "/v2/$1")},
+			ExpectedPath:            "/v2/a/b",
+			ExpectedEscapedPath:     "/v2/a%2Fb",
+			ExpectedForwardedPrefix: "/v1/files",
+		},
+		{
+			Name:                    "should chain rules",
+			Url:                     "http://localhost/service-a/users",
+			Rules:                   []PathRewriteRule{createTestStripPrefixRule("/service-a"), createTestAddPrefixRule("/api")},
+			ExpectedPath:            "/api/users",
+			ExpectedEscapedPath:     "/api/users",
+			ExpectedForwardedPrefix: "/service-a",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			var rewrittenRequest *http.Request
+			handler := CreatePathRewriteMiddleware(test.Rules...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rewrittenRequest = r
+			}))
+
+			// act
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.Url, nil))
+
+			// assert
+			require.NotNil(t, rewrittenRequest)
+			assert.Equal(t, test.ExpectedPath, rewrittenRequest.URL.Path)
+			assert.Equal(t, test.ExpectedEscapedPath, rewrittenRequest.URL.EscapedPath())
+			assert.Equal(t, test.ExpectedForwardedPrefix, rewrittenRequest.Header.Get(ForwardedPrefixHeader))
+		})
+	}
+}
+
+func TestPathRewriteMiddlewareShouldNotForwardSpoofedPrefix(t *testing.T) {
+	testCases := []struct {
+		Name                    string
+		Url                     string
+		ExpectedForwardedPrefix []string
+	}{
+		{Name: "should remove spoofed prefix of path not rewritten", Url: "http://localhost/other/users"},
+		{Name: "should remove spoofed prefix of path rewritten without removing a prefix", Url: "http://localhost/users", ExpectedForwardedPrefix: nil},
+		{Name: "should replace spoofed prefix with removed prefix", Url: "http://localhost/service-a/users", ExpectedForwardedPrefix: []string{"/service-a"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			var rewrittenRequest *http.Request
+			handler := CreatePathRewriteMiddleware(createTestStripPrefixRule("/service-a"), createTestRegexReplaceRule("^/users$", "/v2/users"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rewrittenRequest = r
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
+			request.Header.Set(ForwardedPrefixHeader, "/spoofed")
+
+			// act
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			// assert
+			require.NotNil(t, rewrittenRequest)
+			assert.Equal(t, test.ExpectedForwardedPrefix, rewrittenRequest.Header.Values(ForwardedPrefixHeader))
+			assert.Equal(t, "/spoofed", request.Header.Get(ForwardedPrefixHeader), "the request of the caller must not be modified")
+		})
+	}
+}
+
+func TestPathRewriteRuleCreation(t *testing.T) {
+	_, err := CreateStripPrefixRewriteRule("service-a")
+	assert.Error(t, err)
+
+	_, err = CreateAddPrefixRewriteRule("api")
+	assert.Error(t, err)
+
+	_, err = CreateRegexReplaceRewriteRule("(invalid", "/")
+	assert.Error(t, err)
+}
+
+func TestPathRewriteMiddlewareShouldRejectInvalidRewrittenPath(t *testing.T) {
+	// arrange
+	handler := CreatePathRewriteMiddleware(createTestRegexReplaceRule("^/(.*)$", "/%zz/$1"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	response := httptest.NewRecorder()
+
+	// act
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://localhost/users", nil))
+
+	// assert
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+}
+
+func createTestStripPrefixRule(prefix string) PathRewriteRule {
+	rule, _ := CreateStripPrefixRewriteRule(prefix)
+	return rule
+}
+
+func createTestAddPrefixRule(prefix string) PathRewriteRule {
+	rule, _ := CreateAddPrefixRewriteRule(prefix)
+	return rule
+}
+
+func createTestRegexReplaceRule(pattern string, replacement string) PathRewriteRule {
+	rule, _ := CreateRegexReplaceRewriteRule(pattern, replacement)
+	return rule
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var TooManyRequestsErr = errors.New("too many requests")
+
+// RateLimitKey returns the key of the bucket consuming the request, requests without key share the "" bucket
+type RateLimitKey func(r *http.Request) string
+
+func RateLimitByClientIp() RateLimitKey {
+	return func(r *http.Request) string {
+		return ClientIp(r).String()
+	}
+}
+
+// RateLimitByConsumer uses the consumer identified by the authentication, such as the owner of an api key,
+// the rate limiter must run after the authentication middleware, as the Set methods of the applications ensure,
+// unauthenticated requests share the "" bucket
+func RateLimitByConsumer() RateLimitKey {
+	return func(r *http.Request) string {
+		return ConsumerFromContext(r.Context())
+	}
+}
+
+// RateLimitByTrustedHeader uses a header set by the trusted proxies, such as a tenant resolved by an upstream gateway,
+// requests which do not come from one of them share the "" bucket so the clients cannot get a new bucket by forging the header
+func RateLimitByTrustedHeader(name string, trustedProxies []string) (RateLimitKey, error) {
+	prefixes, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *http.Request) string {
+		remoteAddr, ok := parseRemoteAddr(r.RemoteAddr)
+		if !ok || !containsAddr(prefixes, remoteAddr) {
+			return ""
+		}
+
+		return r.Header.Get(name)
+	}, nil
+}
+
+// RateLimitByJwtSubject uses the sub claim of the bearer token, tokens which cannot be verified share the "" bucket
+// so the clients cannot get a new bucket by forging subjects
+func RateLimitByJwtSubject(verifier JwtVerifier) RateLimitKey {
+	return func(r *http.Request) string {
+		token, ok := BearerToken(r)
+		if !ok {
+			return ""
+		}
+
+		jwt, err := ParseJwt(token)
+		if err != nil || verifier.Verify(jwt) != nil {
+			return ""
+		}
+
+		subjects, ok := jwt.ClaimValues("sub")
+		if !ok || len(subjects) == 0 {
+			return ""
+		}
+
+		return subjects[0]
+	}
+}
+
+func RateLimitByRoute() RateLimitKey {
+	return func(r *http.Request) string {
+		return RouteNameFromContext(r.Context())
+	}
+}
+
+type RateLimitConfig struct {
+	// Requests are allowed per PeriodInMs, the bucket is refilled continuously
+	Requests   int
+	PeriodInMs time.Duration
+	// Burst is the capacity of the bucket
+	Burst int
+	// MaxKeys bounds the buckets of the default store, the least recently used ones are evicted
+	MaxKeys int
+}
+
+func CreateRateLimitConfig(requests int, periodInMs time.Duration) *RateLimitConfig {
+	return &RateLimitConfig{
+		Requests:   requests,
+		PeriodInMs: periodInMs,
+		Burst:      requests,
+		MaxKeys:    10000,
+	}
+}
+
+// ratePerSecond returns the number of tokens added to the buckets each second
+func (c *RateLimitConfig) ratePerSecond() float64 {
+	return float64(c.Requests) * 1000 / float64(c.PeriodInMs)
+}
+
+type RateLimitDecision struct {
+	Allowed bool
+	// Remaining is the number of requests allowed right now
+	Remaining int
+	// RetryAfter is the delay before a rejected request would be allowed
+	RetryAfter time.Duration
+	// Reset is the delay before the bucket is full again
+	Reset time.Duration
+}
+
+// RateLimitStore holds the token buckets, the default one is in memory and a shared store lets several proxies
+// enforce the same limits
+type RateLimitStore interface {
+	Take(key string, ratePerSecond float64, burst int, now time.Time) (RateLimitDecision, error)
+}
+
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex
+	maxKeys int
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+type tokenBucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+}
+
+func CreateMemoryRateLimitStore(maxKeys int) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		maxKeys: max(maxKeys, 1),
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, ratePerSecond float64, burst int, now time.Time) (RateLimitDecision, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bucket := s.bucket(key, burst, now)
+	elapsed := max(now.Sub(bucket.updated).Seconds(), 0)
+	bucket.tokens = min(float64(burst), bucket.tokens+elapsed*ratePerSecond)
+	bucket.updated = now
+
+	decision := RateLimitDecision{Allowed: bucket.tokens >= 1}
+	if decision.Allowed {
+		bucket.tokens--
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - bucket.tokens) / ratePerSecond)
+	}
+
+	decision.Remaining = int(bucket.tokens)
+	decision.Reset = secondsToDuration((float64(burst) - bucket.tokens) / ratePerSecond)
+
+	return decision, nil
+}
+
+// Len returns the number of buckets held by the store
+func (s *MemoryRateLimitStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lru.Len()
+}
+
+// bucket returns the bucket of the key as the most recently used one, a new bucket is full
+func (s *MemoryRateLimitStore) bucket(key string, burst int, now time.Time) *tokenBucket {
+	if element, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(element)
+		return element.Value.(*tokenBucket)
+	}
+
+	if s.lru.Len() >= s.maxKeys {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.buckets, oldest.Value.(*tokenBucket).key)
+	}
+
+	bucket := &tokenBucket{key: key, tokens: float64(burst), updated: now}
+	s.buckets[key] = s.lru.PushFront(bucket)
+
+	return bucket
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimiter rejects with a 429 the requests exceeding the limit of their key,
+// the RateLimit-* headers of the IETF draft are sent on every response
+type RateLimiter struct {
+	logger *slog.Logger
+	key    RateLimitKey
+	config *RateLimitConfig
+	store  RateLimitStore
+	now    func() time.Time
+}
+
+// CreateRateLimiter uses a MemoryRateLimitStore bounded by MaxKeys when store is nil
+func CreateRateLimiter(key RateLimitKey, config *RateLimitConfig, store RateLimitStore, logger *slog.Logger) (*RateLimiter, error) {
+	if config.Requests <= 0 || config.PeriodInMs <= 0 || config.Burst <= 0 {
+		return nil, fmt.Errorf("rate limit requests, period and burst must be positive")
+	}
+
+	if store == nil {
+		store = CreateMemoryRateLimitStore(config.MaxKeys)
+	}
+
+	return &RateLimiter{
+		logger: logger,
+		key:    key,
+		config: config,
+		store:  store,
+		now:    time.Now,
+	}, nil
+}
+
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision, err := l.store.Take(l.key(r), l.config.ratePerSecond(), l.config.Burst, l.now())
+			if err != nil {
+				// an unavailable store must not take the applications down
+				l.logger.Log(r.Context(), slog.LevelError, "cannot apply rate limit, request allowed", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			l.writeHeaders(w, decision)
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+				_ = WriteError(w, r, TooManyRequestsErr)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (l *RateLimiter) writeHeaders(w http.ResponseWriter, decision RateLimitDecision) {
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", l.config.Requests, ceilSeconds(l.config.PeriodInMs*time.Millisecond), l.config.Burst))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(l.config.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	// arrange
+	store := CreateMemoryRateLimitStore(10)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// act
+	first, _ := store.Take("client", 1, 2, now)
+	second, _ := store.Take("client", 1, 2, now)
+	rejected, _ := store.Take("client", 1, 2, now.Add(500*time.Millisecond))
+	refilled, _ := store.Take("client", 1, 2, now.Add(time.Second))
+	other, _ := store.Take("other", 1, 2, now.Add(time.Second))
+
+	// assert
+	assert.Equal(t, RateLimitDecision{Allowed: true, Remaining: 1, Reset: time.Second}, first)
+	assert.Equal(t, RateLimitDecision{Allowed: true, Remaining: 0, Reset: 2 * time.Second}, second)
+	assert.Equal(t, RateLimitDecision{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}, rejected)
+	assert.True(t, refilled.Allowed)
+	assert.True(t, other.Allowed)
+}
+
+func TestMemoryRateLimitStoreShouldEvictLeastRecentlyUsedBuckets(t *testing.T) {
+	// arrange
+	store := CreateMemoryRateLimitStore(2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _ = store.Take("a", 1, 1, now)
+	_, _ = store.Take("b", 1, 1, now)
+	_, _ = store.Take("a", 1, 1, now)
+
+	// act
+	_, _ = store.Take("c", 1, 1, now)
+	evicted, _ := store.Take("b", 1, 1, now)
+	kept, _ := store.Take("c", 1, 1, now)
+
+	// assert
+	assert.Equal(t, 2, store.Len())
+	assert.True(t, evicted.Allowed)
+	assert.False(t, kept.Allowed)
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	// arrange
+	limiter, err := CreateRateLimiter(RateLimitByClientIp(), &RateLimitConfig{Requests: 10, PeriodInMs: 60000, Burst: 2, MaxKeys: 100}, nil, createTestErrorLogger())
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	responses := make([]*httptest.ResponseRecorder, 3)
+	for i := range responses {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		request = request.WithContext(WithClientIp(request.Context(), netip.MustParseAddr("192.0.2.1")))
+		responses[i] = httptest.NewRecorder()
+
+		// act
+		handler.ServeHTTP(responses[i], request)
+	}
+
+	otherClient := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	otherClient = otherClient.WithContext(WithClientIp(otherClient.Context(), netip.MustParseAddr("192.0.2.2")))
+	otherResponse := httptest.NewRecorder()
+	handler.ServeHTTP(otherResponse, otherClient)
+
+	// assert
+	assert.Equal(t, http.StatusOK, responses[0].Code)
+	assert.Equal(t, "1", responses[0].Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", responses[0].Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "10;w=60;burst=2", responses[0].Header().Get("RateLimit-Policy"))
+	assert.Equal(t, http.StatusOK, responses[1].Code)
+	assert.Equal(t, http.StatusTooManyRequests, responses[2].Code)
+	assert.Equal(t, "6", responses[2].Header().Get("Retry-After"))
+	assert.Equal(t, "0", responses[2].Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "12", responses[2].Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, otherResponse.Code)
+}
+
+func TestRateLimiterCreation(t *testing.T) {
+	_, err := CreateRateLimiter(RateLimitByRoute(), &RateLimitConfig{Requests: 10, PeriodInMs: 1000}, nil, createTestErrorLogger())
+	assert.Error(t, err)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	secret := []byte("secret")
+	verifier := CreateHmacJwtVerifier(secret)
+
+	testCases := []struct {
+		Name        string
+		Key         RateLimitKey
+		Url         string
+		RemoteAddr  string
+		Headers     map[string]string
+		Consumer    string
+		ExpectedKey string
+	}{
+		{Name: "should use trusted header", Key: createTestTrustedHeaderKey("X-Tenant", "10.0.0.0/8"), Url: "http://localhost/", RemoteAddr: "10.0.0.1:1234", Headers: map[string]string{"X-Tenant": "acme"}, ExpectedKey: "acme"},
+		{Name: "should not use header of untrusted client", Key: createTestTrustedHeaderKey("X-Tenant", "10.0.0.0/8"), Url: "http://localhost/", RemoteAddr: "203.0.113.7:1234", Headers: map[string]string{"X-Tenant": "acme"}, ExpectedKey: ""},
+		{Name: "should use consumer", Key: RateLimitByConsumer(), Url: "http://localhost/", Consumer: "billing-service", ExpectedKey: "billing-service"},
+		{Name: "should not use consumer of unauthenticated request", Key: RateLimitByConsumer(), Url: "http://localhost/", Headers: map[string]string{"X-Consumer-Id": "billing-service"}, ExpectedKey: ""},
+		{Name: "should use jwt subject", Key: RateLimitByJwtSubject(verifier), Url: "http://localhost/", Headers: map[string]string{"Authorization": "Bearer " + createTestHmacJwt(map[string]any{"sub": "user-1"}, secret)}, ExpectedKey: "user-1"},
+		{Name: "should not use subject of forged jwt", Key: RateLimitByJwtSubject(verifier), Url: "http://localhost/", Headers: map[string]string{"Authorization": "Bearer " + createTestHmacJwt(map[string]any{"sub": "user-1"}, []byte("forged"))}, ExpectedKey: ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
+			if test.RemoteAddr != "" {
+				request.RemoteAddr = test.RemoteAddr
+			}
+			for name, value := range test.Headers {
+				request.Header.Set(name, value)
+			}
+			if test.Consumer != "" {
+				request = request.WithContext(WithConsumer(request.Context(), test.Consumer))
+			}
+
+			// act
+			key := test.Key(request)
+
+			// assert
+			assert.Equal(t, test.ExpectedKey, key)
+		})
+	}
+}
+
+func TestRateLimitByTrustedHeaderCreation(t *testing.T) {
+	_, err := RateLimitByTrustedHeader("X-Tenant", []string{"10.0.0.0/40"})
+	assert.Error(t, err)
+}
+
+func createTestTrustedHeaderKey(name string, trustedProxies ...string) RateLimitKey {
+	key, _ := RateLimitByTrustedHeader(name, trustedProxies)
+	return key
+}
+
+func createTestErrorLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"net/netip"
+)
+
+type contextKey int
+
+const (
+	requestIdContextKey contextKey = iota
+	errorRendererContextKey
+	pathParamsContextKey
+	clientIpContextKey
+	routeNameContextKey
+	forwardedPrefixContextKey
+	hostHeaderPolicyContextKey
+	requestLimitsContextKey
+	consumerContextKey
+)
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey).(string)
+	return requestId
+}
+
+func WithPathParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, pathParamsContextKey, params)
+}
+
+func PathParamsFromContext(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(pathParamsContextKey).(map[string]string)
+	return params
+}
+
+func WithRouteName(ctx context.Context, routeName string) context.Context {
+	return context.WithValue(ctx, routeNameContextKey, routeName)
+}
+
+func RouteNameFromContext(ctx context.Context) string {
+	routeName, _ := ctx.Value(routeNameContextKey).(string)
+	return routeName
+}
+
+// WithForwardedPrefix records the path prefix removed before forwarding the request upstream
+func WithForwardedPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, forwardedPrefixContextKey, prefix)
+}
+
+func ForwardedPrefixFromContext(ctx context.Context) string {
+	prefix, _ := ctx.Value(forwardedPrefixContextKey).(string)
+	return prefix
+}
+
+func WithHostHeaderPolicy(ctx context.Context, policy *HostHeaderPolicy) context.Context {
+	return context.WithValue(ctx, hostHeaderPolicyContextKey, policy)
+}
+
+func HostHeaderPolicyFromContext(ctx context.Context) *HostHeaderPolicy {
+	policy, ok := ctx.Value(hostHeaderPolicyContextKey).(*HostHeaderPolicy)
+	if !ok || policy == nil {
+		return CreateUpstreamHostHeaderPolicy()
+	}
+
+	return policy
+}
+
+// WithRequestLimits sets the limits of the listener receiving the request, nil removes the limits
+func WithRequestLimits(ctx context.Context, limits *RequestLimitsConfig) context.Context {
+	return context.WithValue(ctx, requestLimitsContextKey, limits)
+}
+
+func RequestLimitsFromContext(ctx context.Context) *RequestLimitsConfig {
+	limits, ok := ctx.Value(requestLimitsContextKey).(*RequestLimitsConfig)
+	if !ok || limits == nil {
+		return unlimitedRequestLimits
+	}
+
+	return limits
+}
+
+// WithConsumer sets the identity of the authenticated consumer of the request
+func WithConsumer(ctx context.Context, consumer string) context.Context {
+	return context.WithValue(ctx, consumerContextKey, consumer)
+}
+
+func ConsumerFromContext(ctx context.Context) string {
+	consumer, _ := ctx.Value(consumerContextKey).(string)
+	return consumer
+}
+
+func WithErrorRenderer(ctx context.Context, renderer ErrorRenderer) context.Context {
+	return context.WithValue(ctx, errorRendererContextKey, renderer)
+}
+
+func ErrorRendererFromContext(ctx context.Context) ErrorRenderer {
+	renderer, ok := ctx.Value(errorRendererContextKey).(ErrorRenderer)
+	if !ok || renderer == nil {
+		return defaultErrorRenderer
+	}
+
+	return renderer
+}
+
+func WithClientIp(ctx context.Context, addr netip.Addr) context.Context {
+	return context.WithValue(ctx, clientIpContextKey, addr)
+}
+
+func ClientIpFromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(clientIpContextKey).(netip.Addr)
+	return addr, ok
+}
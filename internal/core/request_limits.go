@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+)
+
+var RequestBodyTooLargeErr = errors.New("request body too large")
+var RequestHeaderFieldsTooLargeErr = errors.New("request header fields too large")
+var UriTooLongErr = errors.New("request uri too long")
+
+// RequestLimitsConfig bounds the requests of a listener, a zero limit is not enforced
+type RequestLimitsConfig struct {
+	MaxBodyBytes   int64
+	MaxHeaderCount int
+	// MaxHeaderBytes counts the names and the values of the header fields
+	MaxHeaderBytes int
+	MaxUriLength   int
+}
+
+// unlimitedRequestLimits applies to the requests whose listener has no limits
+var unlimitedRequestLimits = &RequestLimitsConfig{}
+
+// CreateDefaultRequestLimitsConfig returns limits suited to most APIs, the listeners only enforce them when they are set
+func CreateDefaultRequestLimitsConfig() *RequestLimitsConfig {
+	return &RequestLimitsConfig{
+		MaxBodyBytes:   10 * 1024 * 1024,
+		MaxHeaderCount: 100,
+		MaxHeaderBytes: http.DefaultMaxHeaderBytes,
+		MaxUriLength:   8 * 1024,
+	}
+}
+
+// CreateRequestLimitsMiddleware rejects the requests exceeding the limits set on their context by WithRequestLimits
+func CreateRequestLimitsMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limits := RequestLimitsFromContext(r.Context())
+			if err := checkRequestLimits(r, limits); err != nil {
+				_ = WriteError(w, r, err)
+				return
+			}
+
+			if limits.MaxBodyBytes > 0 && r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBodyBytes)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func checkRequestLimits(r *http.Request, limits *RequestLimitsConfig) error {
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	if limits.MaxUriLength > 0 && len(uri) > limits.MaxUriLength {
+		return UriTooLongErr
+	}
+
+	count, size := 0, 0
+	for name, values := range r.Header {
+		count += len(values)
+		for _, value := range values {
+			size += len(name) + len(value)
+		}
+	}
+
+	if (limits.MaxHeaderCount > 0 && count > limits.MaxHeaderCount) || (limits.MaxHeaderBytes > 0 && size > limits.MaxHeaderBytes) {
+		return RequestHeaderFieldsTooLargeErr
+	}
+
+	if limits.MaxBodyBytes > 0 && r.ContentLength > limits.MaxBodyBytes {
+		return RequestBodyTooLargeErr
+	}
+
+	return nil
+}
+
+// isRequestBodyTooLarge reports whether the body was cut by the limits middleware while being read
+func isRequestBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLimitsMiddleware(t *testing.T) {
+	limits := &RequestLimitsConfig{MaxBodyBytes: 8, MaxHeaderCount: 3, MaxHeaderBytes: 64, MaxUriLength: 32}
+
+	testCases := []struct {
+		Name           string
+		Url            string
+		Headers        map[string]string
+		Body           io.Reader
+		ExpectedStatus int
+	}{
+		{Name: "should accept request within limits", Url: "http://localhost/users", Body: strings.NewReader("12345678"), ExpectedStatus: http.StatusOK},
+		{Name: "should reject too long uri", Url: "http://localhost/users?filter=" + strings.Repeat("a", 32), ExpectedStatus: http.StatusRequestURITooLong},
+		{Name: "should reject too many headers", Url: "http://localhost/", Headers: map[string]string{"A": "1", "B": "2", "C": "3", "D": "4"}, ExpectedStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{Name: "should reject too large headers", Url: "http://localhost/", Headers: map[string]string{"Cookie": strings.Repeat("a", 64)}, ExpectedStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{Name: "should reject too large content length", Url: "http://localhost/", Body: strings.NewReader("123456789"), ExpectedStatus: http.StatusRequestEntityTooLarge},
+		{Name: "should reject too large streamed body", Url: "http://localhost/", Body: io.MultiReader(strings.NewReader("12345"), strings.NewReader("6789")), ExpectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			handler := CreateRequestLimitsMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := io.ReadAll(r.Body); err != nil {
+					if isRequestBodyTooLarge(err) {
+						_ = WriteError(w, r, RequestBodyTooLargeErr)
+						return
+					}
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, test.Url, test.Body)
+			request = request.WithContext(WithRequestLimits(request.Context(), limits))
+			for name, value := range test.Headers {
+				request.Header.Set(name, value)
+			}
+
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+}
+
+func TestRequestLimitsMiddlewareShouldNotEnforceMissingLimits(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Limits *RequestLimitsConfig
+	}{
+		{Name: "should not limit request without listener limits", Limits: nil},
+		{Name: "should not enforce zero limits", Limits: &RequestLimitsConfig{}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			var body []byte
+			handler := CreateRequestLimitsMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost/users?filter="+strings.Repeat("a", 16*1024), strings.NewReader(strings.Repeat("b", 11*1024*1024)))
+			request = request.WithContext(WithRequestLimits(request.Context(), test.Limits))
+			for i := 0; i < 150; i++ {
+				request.Header.Set(fmt.Sprintf("X-Header-%d", i), "value")
+			}
+
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, http.StatusOK, response.Code)
+			assert.Len(t, body, 11*1024*1024)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// requestTemplate renders a value from the request, it can reference {client_ip}, {request_id}, {route}, {param.<name>},
+// {scheme}, {host} without its port, {path}, {query} and {request_uri}, {{ and }} escape braces
+type requestTemplate struct {
+	segments []requestTemplateSegment
+}
+
+type requestTemplateSegment struct {
+	literal  string
+	variable string
+}
+
+const pathParamTemplateVariablePrefix = "param."
+
+func parseRequestTemplate(template string) (*requestTemplate, error) {
+	segments := make([]requestTemplateSegment, 0)
+	var literal strings.Builder
+
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case template[i] == '}':
+			return nil, fmt.Errorf("unexpected } at position %d", i+1)
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated variable at position %d", i+1)
+			}
+
+			variable := template[i+1 : i+end]
+			if !isRequestTemplateVariable(variable) {
+				return nil, fmt.Errorf("unknown variable %q at position %d", variable, i+1)
+			}
+
+			segments = append(segments, requestTemplateSegment{literal: literal.String()}, requestTemplateSegment{variable: variable})
+			literal.Reset()
+			i += end
+		default:
+			literal.WriteByte(template[i])
+		}
+	}
+
+	segments = append(segments, requestTemplateSegment{literal: literal.String()})
+	return &requestTemplate{segments: segments}, nil
+}
+
+func isRequestTemplateVariable(variable string) bool {
+	switch variable {
+	case "client_ip", "request_id", "route", "scheme", "host", "path", "query", "request_uri":
+		return true
+	}
+
+	return strings.HasPrefix(variable, pathParamTemplateVariablePrefix) && len(variable) > len(pathParamTemplateVariablePrefix)
+}
+
+func (t *requestTemplate) render(r *http.Request) string {
+	var value strings.Builder
+	for _, segment := range t.segments {
+		if segment.variable == "" {
+			value.WriteString(segment.literal)
+			continue
+		}
+
+		value.WriteString(resolveRequestTemplateVariable(segment.variable, r))
+	}
+
+	return value.String()
+}
+
+func resolveRequestTemplateVariable(variable string, r *http.Request) string {
+	switch variable {
+	case "client_ip":
+		if addr := ClientIp(r); addr.IsValid() {
+			return addr.String()
+		}
+
+		return ""
+	case "request_id":
+		return RequestIdFromContext(r.Context())
+	case "route":
+		return RouteNameFromContext(r.Context())
+	case "scheme":
+		return requestScheme(r)
+	case "host":
+		return hostWithoutPort(r.Host)
+	case "path":
+		return r.URL.EscapedPath()
+	case "query":
+		return r.URL.RawQuery
+	case "request_uri":
+		return r.URL.RequestURI()
+	}
+
+	return PathParamsFromContext(r.Context())[strings.TrimPrefix(variable, pathParamTemplateVariablePrefix)]
+}
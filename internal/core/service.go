@@ -5,15 +5,20 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Service struct {
-	quitChannel chan struct{}
-	logger      *slog.Logger
-	Config      *ServiceConfig
-	Available   bool
-	Hostname    string
+	quitChannel           chan struct{}
+	logger                *slog.Logger
+	mutex                 sync.RWMutex
+	availableSince        time.Time
+	slowStart             *SlowStartConfig
+	onAvailabilityChanged func()
+	Config                *ServiceConfig
+	Available             bool
+	Hostname              string
 }
 
 func CreateService(logger *slog.Logger, cfg *ServiceConfig) *Service {
@@ -28,42 +33,92 @@ func CreateService(logger *slog.Logger, cfg *ServiceConfig) *Service {
 func (s *Service) Start(ctx context.Context, cfg *HealthCheckConfig) {
 	s.quitChannel = make(chan struct{})
 	tickerChannel := time.NewTicker(cfg.IntervalInMs * time.Millisecond)
-	healthChannel := make(chan bool)
+	firstCheckChannel := make(chan struct{})
 
 	go func() {
 		s.logger.Log(ctx, slog.LevelInfo, "starting healthCheck")
+		firstCheck := true
 		for {
 			select {
 			case <-tickerChannel.C:
-				s.logger.Log(ctx, slog.LevelDebug, "calling healthCheck endpoint")
-				resp, err := http.DefaultClient.Get(fmt.Sprintf("http://%s%s", s.Hostname, cfg.Path))
-				if err != nil || resp.StatusCode >= http.StatusBadRequest {
-					s.logger.Log(ctx, slog.LevelWarn, "application service is down")
-					healthChannel <- false
-				} else {
-					if !s.Available {
-						s.logger.Log(ctx, slog.LevelInfo, "application service is up")
-					} else {
-						s.logger.Log(ctx, slog.LevelDebug, "application service is still up")
-					}
-					healthChannel <- true
+				s.updateAvailability(ctx, s.checkHealth(ctx, cfg))
+				if firstCheck {
+					firstCheck = false
+					close(firstCheckChannel)
 				}
 			case <-s.quitChannel:
 				s.logger.Log(ctx, slog.LevelInfo, "stopping healthCheck")
 				tickerChannel.Stop()
-				close(healthChannel)
 				return
 			}
 		}
 	}()
 
-	select {
-	case available := <-healthChannel:
-		s.Available = available
-	}
+	<-firstCheckChannel
 }
 
 func (s *Service) Stop() {
+	s.mutex.Lock()
 	s.Available = false
+	s.mutex.Unlock()
+
 	close(s.quitChannel)
-}
\ No newline at end of file
+}
+
+func (s *Service) IsAvailable() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.Available
+}
+
+func (s *Service) EffectiveWeight() int {
+	return s.effectiveWeightAt(time.Now())
+}
+
+func (s *Service) effectiveWeightAt(now time.Time) int {
+	s.mutex.RLock()
+	availableSince := s.availableSince
+	s.mutex.RUnlock()
+
+	return s.slowStart.computeWeight(s.Config.Weight, now.Sub(availableSince))
+}
+
+func (s *Service) checkHealth(ctx context.Context, cfg *HealthCheckConfig) bool {
+	s.logger.Log(ctx, slog.LevelDebug, "calling healthCheck endpoint")
+	resp, err := http.DefaultClient.Get(fmt.Sprintf("http://%s%s", s.Hostname, cfg.Path))
+	if err != nil {
+		return false
+	}
+
+	defer resp.Body.Close()
+	return resp.StatusCode < http.StatusBadRequest
+}
+
+func (s *Service) updateAvailability(ctx context.Context, available bool) {
+	if s.setAvailable(ctx, available) && s.onAvailabilityChanged != nil {
+		s.onAvailabilityChanged()
+	}
+}
+
+func (s *Service) setAvailable(ctx context.Context, available bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !available {
+		s.logger.Log(ctx, slog.LevelWarn, "application service is down")
+		changed := s.Available
+		s.Available = false
+		return changed
+	}
+
+	if s.Available {
+		s.logger.Log(ctx, slog.LevelDebug, "application service is still up")
+		return false
+	}
+
+	s.logger.Log(ctx, slog.LevelInfo, "application service is up")
+	s.Available = true
+	s.availableSince = time.Now()
+	return true
+}
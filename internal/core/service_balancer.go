@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -18,6 +20,13 @@ type ServiceBalancerConfig struct {
 	UpstreamResolutionTimeoutInMs int
 	UpstreamRequestTimeoutInMs    int
 	Strategy                      ServiceBalancingStrategy
+	SlowStart                     *SlowStartConfig
+	FailoverThresholdPercent      int
+	// LocalZone is the zone of the proxy, the zone set with SetDefaultLocalZone is used when empty
+	LocalZone string
+	// LocalityOverprovisioningPercent scales the local healthy capacity before spilling to the other zones,
+	// at 140, as Envoy does, no request is spilled while more than 71% of the local capacity is healthy
+	LocalityOverprovisioningPercent int
 }
 
 func CreateDefaultHealthCheckConfig(intervalInMs time.Duration) *HealthCheckConfig {
@@ -29,36 +38,44 @@ func CreateDefaultHealthCheckConfig(intervalInMs time.Duration) *HealthCheckConf
 
 func CreateRoundRobinServiceBalancerConfig(healthCheck *HealthCheckConfig, upstreamResolutionTimeoutInMs int, upstreamRequestTimeoutInMs int) *ServiceBalancerConfig {
 	return &ServiceBalancerConfig{
-		HealthCheck:                   healthCheck,
-		UpstreamResolutionTimeoutInMs: upstreamResolutionTimeoutInMs,
-		UpstreamRequestTimeoutInMs:    upstreamRequestTimeoutInMs,
-		Strategy:                      &RoundRobinStrategy{},
+		HealthCheck:                     healthCheck,
+		UpstreamResolutionTimeoutInMs:   upstreamResolutionTimeoutInMs,
+		UpstreamRequestTimeoutInMs:      upstreamRequestTimeoutInMs,
+		Strategy:                        &RoundRobinStrategy{},
+		LocalityOverprovisioningPercent: 140,
 	}
 }
 
 func CreateWeightedRoundRobinServiceBalancerConfig(healthCheck *HealthCheckConfig, upstreamResolutionTimeoutInMs int, upstreamRequestTimeoutInMs int) *ServiceBalancerConfig {
 	return &ServiceBalancerConfig{
-		HealthCheck:                   healthCheck,
-		UpstreamResolutionTimeoutInMs: upstreamResolutionTimeoutInMs,
-		UpstreamRequestTimeoutInMs:    upstreamRequestTimeoutInMs,
-		Strategy:                      &WeightedRoundRobinStrategy{},
+		HealthCheck:                     healthCheck,
+		UpstreamResolutionTimeoutInMs:   upstreamResolutionTimeoutInMs,
+		UpstreamRequestTimeoutInMs:      upstreamRequestTimeoutInMs,
+		Strategy:                        &WeightedRoundRobinStrategy{},
+		LocalityOverprovisioningPercent: 140,
 	}
 }
 
 func CreateInterleavedRoundRobinServiceBalancerConfig(healthCheck *HealthCheckConfig, upstreamResolutionTimeoutInMs int, upstreamRequestTimeoutInMs int) *ServiceBalancerConfig {
 	return &ServiceBalancerConfig{
-		HealthCheck:                   healthCheck,
-		UpstreamResolutionTimeoutInMs: upstreamResolutionTimeoutInMs,
-		UpstreamRequestTimeoutInMs:    upstreamRequestTimeoutInMs,
-		Strategy:                      &InterleavedRoundRobinStrategy{},
+		HealthCheck:                     healthCheck,
+		UpstreamResolutionTimeoutInMs:   upstreamResolutionTimeoutInMs,
+		UpstreamRequestTimeoutInMs:      upstreamRequestTimeoutInMs,
+		Strategy:                        &InterleavedRoundRobinStrategy{},
+		LocalityOverprovisioningPercent: 140,
 	}
 }
 
 type ServiceBalancer struct {
-	logger   *slog.Logger
-	factory  *HttpRequestForwarderFactory
-	Config   *ServiceBalancerConfig
-	Services []*Service
+	logger              *slog.Logger
+	factory             *HttpRequestForwarderFactory
+	mutex               sync.Mutex
+	availabilityChannel chan struct{}
+	zoneSpillCredit     int
+	defaultLocalZone    string
+	concurrencyLimiter  *ConcurrencyLimiter
+	Config              *ServiceBalancerConfig
+	Services            []*Service
 }
 
 type HealthCheckConfig struct {
@@ -70,10 +87,20 @@ func (sc *ServiceConfig) SetWeight(weight int) {
 	sc.Weight = weight
 }
 
+func (sc *ServiceConfig) SetPriority(priority int) {
+	sc.Priority = priority
+}
+
+func (sc *ServiceConfig) SetZone(zone string) {
+	sc.Zone = zone
+}
+
 type ServiceConfig struct {
-	Host   string
-	Port   int
-	Weight int
+	Host     string
+	Port     int
+	Weight   int
+	Priority int
+	Zone     string
 }
 
 func CreateRoundRobinServiceConfig(host string, port int) *ServiceConfig {
@@ -100,7 +127,36 @@ var (
 )
 
 func (lb *ServiceBalancer) ElectNextService() (*Service, error) {
-	return lb.Config.Strategy.ElectNextService(lb.Services)
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.electNextService()
+}
+
+func (lb *ServiceBalancer) electNextService() (*Service, error) {
+	eligibleServices := lb.eligibleServices()
+
+	healthyServices := filterHealthyServices(lb.selectLocalityServices(eligibleServices))
+	if len(healthyServices) == 0 {
+		healthyServices = filterHealthyServices(eligibleServices)
+	}
+
+	if len(healthyServices) == 0 {
+		return nil, ServiceUnavailableErr
+	}
+
+	return lb.Config.Strategy.ElectNextService(healthyServices)
+}
+
+func filterHealthyServices(services []*Service) []*Service {
+	healthyServices := make([]*Service, 0, len(services))
+	for _, service := range services {
+		if service.IsAvailable() {
+			healthyServices = append(healthyServices, service)
+		}
+	}
+
+	return healthyServices
 }
 
 type RoundRobinStrategy struct {
@@ -108,51 +164,143 @@ type RoundRobinStrategy struct {
 }
 
 type WeightedRoundRobinStrategy struct {
-	currentIndex int
+	currentIndex  int
+	currentWeight int
 }
 
 type InterleavedRoundRobinStrategy struct {
 	currentIndex int
+	currentRound int
 }
 
+// ServiceBalancingStrategy elects a service among the healthy services of the balancer, it is never called concurrently
 type ServiceBalancingStrategy interface {
 	ElectNextService(services []*Service) (*Service, error)
 }
 
 func (rrs *RoundRobinStrategy) ElectNextService(services []*Service) (*Service, error) {
+	if len(services) == 0 {
+		return nil, ServiceUnavailableErr
+	}
+
+	rrs.currentIndex = rrs.currentIndex % len(services)
 	nextService := services[rrs.currentIndex]
 	rrs.currentIndex = (rrs.currentIndex + 1) % len(services)
 
-	if nextService.Available {
-		return nextService, nil
-	}
-
-	return nil, nil
+	return nextService, nil
 }
 
+// ElectNextService serves each service as many times in a row as its effective weight, the services are iterated
+// in the order of their configured weights so a weight changing during a slow start ramp never shifts the others
 func (rrs *WeightedRoundRobinStrategy) ElectNextService(services []*Service) (*Service, error) {
-	return nil, errors.New("not implemented")
+	if len(services) == 0 {
+		return nil, ServiceUnavailableErr
+	}
+
+	services = orderServicesByConfiguredWeight(services)
+	weights := effectiveWeights(services)
+
+	rrs.currentIndex = rrs.currentIndex % len(services)
+	nextService := services[rrs.currentIndex]
+
+	rrs.currentWeight++
+	if rrs.currentWeight >= weights[rrs.currentIndex] {
+		rrs.currentWeight = 0
+		rrs.currentIndex = (rrs.currentIndex + 1) % len(services)
+	}
+
+	return nextService, nil
 }
 
+// ElectNextService serves the services in rounds, a service takes part in as many rounds as its effective weight,
+// the services are iterated in the order of their configured weights so a weight changing during a slow start ramp
+// never shifts the others
 func (rrs *InterleavedRoundRobinStrategy) ElectNextService(services []*Service) (*Service, error) {
-	return nil, errors.New("not implemented")
+	if len(services) == 0 {
+		return nil, ServiceUnavailableErr
+	}
+
+	services = orderServicesByConfiguredWeight(services)
+	weights := effectiveWeights(services)
+	maxWeight := slices.Max(weights)
+
+	for {
+		if rrs.currentRound >= maxWeight {
+			rrs.currentRound = 0
+		}
+
+		rrs.currentIndex = rrs.currentIndex % len(services)
+		index := rrs.currentIndex
+		round := rrs.currentRound
+
+		rrs.currentIndex++
+		if rrs.currentIndex == len(services) {
+			rrs.currentIndex = 0
+			rrs.currentRound++
+		}
+
+		if weights[index] > round {
+			return services[index], nil
+		}
+	}
+}
+
+// orderServicesByConfiguredWeight orders the services by decreasing configured weight, keeping the registration
+// order of the ones with the same weight, the order is the same whatever the progress of their slow start
+func orderServicesByConfiguredWeight(services []*Service) []*Service {
+	return slices.SortedStableFunc(slices.Values(services), func(a, b *Service) int {
+		return max(1, b.Config.Weight) - max(1, a.Config.Weight)
+	})
+}
+
+// effectiveWeights returns the weights of the services, in their order, divided by their greatest common divisor
+func effectiveWeights(services []*Service) []int {
+	now := time.Now()
+	weights := make([]int, len(services))
+	divisor := 0
+	for i, service := range services {
+		weights[i] = service.effectiveWeightAt(now)
+		divisor = gcd(divisor, weights[i])
+	}
+
+	for i := range weights {
+		weights[i] /= divisor
+	}
+
+	return weights
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
 }
 
 func CreateServiceBalancer(factory *HttpRequestForwarderFactory, cfg *ServiceBalancerConfig, logger *slog.Logger) *ServiceBalancer {
 	return &ServiceBalancer{
-		logger:   logger,
-		Services: make([]*Service, 0),
-		factory:  factory,
-		Config:   cfg,
+		logger:              logger,
+		Services:            make([]*Service, 0),
+		factory:             factory,
+		availabilityChannel: make(chan struct{}),
+		Config:              cfg,
 	}
 }
 
 func (lb *ServiceBalancer) RegisterService(ctx context.Context, cfg *ServiceConfig) *Service {
 	service := CreateService(lb.logger, cfg)
+	service.slowStart = lb.Config.SlowStart
+	service.onAvailabilityChanged = lb.notifyAvailabilityChanged
 
 	lb.logger.Log(ctx, slog.LevelInfo, "registering service")
 	service.Start(ctx, lb.Config.HealthCheck)
+
+	lb.mutex.Lock()
 	lb.Services = append(lb.Services, service)
+	lb.mutex.Unlock()
+
+	lb.notifyAvailabilityChanged()
 	lb.logger.Log(ctx, slog.LevelInfo, "service registered")
 
 	return service
@@ -162,6 +310,9 @@ func (lb *ServiceBalancer) UnregisterService(ctx context.Context, host string) e
 	logger := lb.logger.With(slog.String("service_host", host))
 	logger.Log(ctx, slog.LevelInfo, "unregistering service")
 
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
 	serviceUnregistered := false
 	serviceToUnregisterFound := false
 
@@ -193,9 +344,41 @@ func (lb *ServiceBalancer) UnregisterService(ctx context.Context, host string) e
 	return nil
 }
 
+// upstreamClient returns the upstream redirects to the client instead of following them
+var upstreamClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// SetConcurrencyLimiter bounds the requests forwarded at once by the balancer, a slot is held until the response body is closed
+func (lb *ServiceBalancer) SetConcurrencyLimiter(limiter *ConcurrencyLimiter) {
+	lb.concurrencyLimiter = limiter
+}
+
 func (lb *ServiceBalancer) HandleRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
 	lb.logger.Log(ctx, slog.LevelDebug, "handling request with load balancing strategy")
 
+	if lb.concurrencyLimiter == nil {
+		return lb.forwardRequest(ctx, req)
+	}
+
+	permit, err := lb.concurrencyLimiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := lb.forwardRequest(ctx, req)
+	if err != nil {
+		permit.Release(true)
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, permit: permit, failed: resp.StatusCode >= http.StatusInternalServerError}
+	return resp, nil
+}
+
+func (lb *ServiceBalancer) forwardRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
 	service, err := lb.GetAvailableService(ctx)
 	if err != nil {
 		return nil, err
@@ -208,8 +391,12 @@ func (lb *ServiceBalancer) HandleRequest(ctx context.Context, req *http.Request)
 	}
 
 	lb.logger.Log(ctx, slog.LevelInfo, "forwarding request to upstream service")
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := upstreamClient.Do(request.WithContext(ctx))
 	if err != nil {
+		if isRequestBodyTooLarge(err) {
+			return nil, fmt.Errorf("failed to forward request to upstream service: %w", RequestBodyTooLargeErr)
+		}
+
 		return nil, fmt.Errorf("failed to forward request to upstream service: %w", BadGatewayErr)
 	}
 
@@ -223,26 +410,45 @@ func (lb *ServiceBalancer) HandleRequest(ctx context.Context, req *http.Request)
 func (lb *ServiceBalancer) GetAvailableService(ctx context.Context) (*Service, error) {
 	lb.logger.Log(ctx, slog.LevelDebug, "retrieving an available service")
 
-	timeCtx, cancel := context.WithTimeout(ctx, time.Duration(lb.Config.UpstreamResolutionTimeoutInMs)*time.Millisecond)
-	defer cancel()
-
+	var timeCtx context.Context
 	for {
-		select {
-		case <-timeCtx.Done():
-			return nil, fmt.Errorf("failed to retrieve an available service within the allocated time: %w", BadGatewayErr)
-		default:
-			service, err := lb.ElectNextService()
-			if err != nil {
-				return nil, fmt.Errorf("failed to elect next available upstream service: %w", err)
-			}
-
-			if service == nil {
-				lb.logger.Log(ctx, slog.LevelDebug, "no available upstream service", slog.Any("error", err))
-				continue
-			}
+		lb.mutex.Lock()
+		service, err := lb.electNextService()
+		availabilityChannel := lb.availabilityChannel
+		lb.mutex.Unlock()
 
+		if err == nil {
 			lb.logger.Log(ctx, slog.LevelDebug, "found an available upstream service")
 			return service, nil
 		}
+
+		if !errors.Is(err, ServiceUnavailableErr) {
+			return nil, fmt.Errorf("failed to elect next available upstream service: %w", err)
+		}
+
+		if lb.Config.UpstreamResolutionTimeoutInMs <= 0 {
+			return nil, fmt.Errorf("no healthy upstream service: %w", ServiceUnavailableErr)
+		}
+
+		if timeCtx == nil {
+			var cancel context.CancelFunc
+			timeCtx, cancel = context.WithTimeout(ctx, time.Duration(lb.Config.UpstreamResolutionTimeoutInMs)*time.Millisecond)
+			defer cancel()
+		}
+
+		lb.logger.Log(ctx, slog.LevelDebug, "no available upstream service, waiting for a service to become available")
+		select {
+		case <-timeCtx.Done():
+			return nil, fmt.Errorf("failed to retrieve an available service within the allocated time: %w", ServiceUnavailableErr)
+		case <-availabilityChannel:
+		}
 	}
-}
\ No newline at end of file
+}
+
+func (lb *ServiceBalancer) notifyAvailabilityChanged() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	close(lb.availabilityChannel)
+	lb.availabilityChannel = make(chan struct{})
+}
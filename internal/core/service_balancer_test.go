@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestServiceBalancer(t *testing.T) {
@@ -131,6 +132,131 @@ func TestServiceBalancer(t *testing.T) {
 	})
 }
 
+func TestServiceBalancerAvailability(t *testing.T) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	t.Run("should fail fast when no service is registered", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1)
+		sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+
+		// act
+		_, err := sb.GetAvailableService(context.Background())
+
+		// assert
+		assert.ErrorIs(t, err, ServiceUnavailableErr)
+	})
+
+	t.Run("should return service unavailable when no service became healthy in time", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 10, 1)
+		sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+		service := createAvailableTestService(nil, 1, time.Now())
+		service.Available = false
+		sb.Services = append(sb.Services, service)
+
+		// act
+		start := time.Now()
+		_, err := sb.GetAvailableService(context.Background())
+
+		// assert
+		assert.ErrorIs(t, err, ServiceUnavailableErr)
+		assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	})
+
+	t.Run("should wait for a service to become healthy", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 5000, 1)
+		sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+		service := createAvailableTestService(nil, 1, time.Now())
+		service.Available = false
+		service.onAvailabilityChanged = sb.notifyAvailabilityChanged
+		sb.Services = append(sb.Services, service)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			service.updateAvailability(context.Background(), true)
+		}()
+
+		// act
+		svc, err := sb.GetAvailableService(context.Background())
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, service, svc)
+	})
+
+	t.Run("should only elect healthy services", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		cfg := CreateInterleavedRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1)
+		sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+		healthyService := createAvailableTestService(nil, 1, time.Now())
+		unhealthyService := createAvailableTestService(nil, 5, time.Now())
+		unhealthyService.Available = false
+		sb.Services = append(sb.Services, unhealthyService, healthyService)
+
+		for range 5 {
+			// act
+			svc, err := sb.GetAvailableService(context.Background())
+
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, healthyService, svc)
+		}
+	})
+}
+
+func BenchmarkServiceBalancer(b *testing.B) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	configs := map[string]*ServiceBalancerConfig{
+		"round_robin":             CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1),
+		"weighted_round_robin":    CreateWeightedRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1),
+		"interleaved_round_robin": CreateInterleavedRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1),
+	}
+
+	for name, cfg := range configs {
+		b.Run(name, func(b *testing.B) {
+			sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+			for i := range 10 {
+				sb.Services = append(sb.Services, createAvailableTestService(nil, i+1, time.Now()))
+			}
+
+			ctx := context.Background()
+			b.ResetTimer()
+			for range b.N {
+				_, _ = sb.GetAvailableService(ctx)
+			}
+		})
+	}
+
+	b.Run("no_healthy_service", func(b *testing.B) {
+		cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 0, 1)
+		sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+		for range 10 {
+			service := createAvailableTestService(nil, 1, time.Now())
+			service.Available = false
+			sb.Services = append(sb.Services, service)
+		}
+
+		ctx := context.Background()
+		b.ResetTimer()
+		for range b.N {
+			_, _ = sb.GetAvailableService(ctx)
+		}
+	})
+}
+
 func waitForAllServicesToBeAvailable(sb *ServiceBalancer) {
 	for {
 		allServiceAvailable := true
@@ -0,0 +1,66 @@
+package core
+
+// SetDefaultLocalZone sets the zone used when the config has no LocalZone, such as the locality of the proxy,
+// it applies to the next elections
+func (lb *ServiceBalancer) SetDefaultLocalZone(zone string) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.defaultLocalZone = zone
+}
+
+// LocalZone returns the zone whose services are preferred, empty when the zones are ignored
+func (lb *ServiceBalancer) LocalZone() string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.localZone()
+}
+
+func (lb *ServiceBalancer) localZone() string {
+	if lb.Config.LocalZone != "" {
+		return lb.Config.LocalZone
+	}
+
+	return lb.defaultLocalZone
+}
+
+// selectLocalityServices keeps the services of the local zone as long as their healthy capacity, scaled by
+// the overprovisioning, covers the traffic, otherwise requests are spilled to the other zones proportionally
+// to the missing local capacity
+func (lb *ServiceBalancer) selectLocalityServices(services []*Service) []*Service {
+	localZone := lb.localZone()
+	if localZone == "" {
+		return services
+	}
+
+	localServices := make([]*Service, 0, len(services))
+	remoteServices := make([]*Service, 0, len(services))
+	for _, service := range services {
+		if service.Config.Zone == localZone {
+			localServices = append(localServices, service)
+		} else {
+			remoteServices = append(remoteServices, service)
+		}
+	}
+
+	if len(localServices) == 0 || len(remoteServices) == 0 {
+		return services
+	}
+
+	localHealthyPercent, healthy := computeHealthyCapacity(localServices)
+	if !healthy {
+		return remoteServices
+	}
+
+	overprovisioningPercent := max(100, lb.Config.LocalityOverprovisioningPercent)
+	localCapacityPercent := min(100, localHealthyPercent*overprovisioningPercent/100)
+
+	lb.zoneSpillCredit += 100 - localCapacityPercent
+	if lb.zoneSpillCredit >= 100 {
+		lb.zoneSpillCredit -= 100
+		return remoteServices
+	}
+
+	return localServices
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestServiceLocality(t *testing.T) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	testCases := []struct {
+		Name                    string
+		LocalZone               string
+		OverprovisioningPercent int
+		LocalAvailability       []bool
+		ExpectLocalElections    bool
+		ExpectRemoteElections   bool
+	}{
+		{"should ignore zones when no local zone is configured", "", 140, []bool{true, true}, true, true},
+		{"should only elect local services when they are all healthy", "eu-west-1a", 140, []bool{true, true}, true, false},
+		{"should only elect remote services when local services are down", "eu-west-1a", 140, []bool{false, false}, false, true},
+		{"should spill to remote services when local capacity is insufficient", "eu-west-1a", 140, []bool{true, false}, true, true},
+		{"should not spill while overprovisioned local capacity is sufficient", "eu-west-1a", 140, []bool{true, true, true, false}, true, false},
+		{"should spill as soon as local capacity is degraded without overprovisioning", "eu-west-1a", 100, []bool{true, true, true, false}, true, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 100, 1)
+			cfg.LocalZone = test.LocalZone
+			cfg.LocalityOverprovisioningPercent = test.OverprovisioningPercent
+			sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+
+			remote := createAvailableTestService(nil, 1, time.Now())
+			remote.Config.SetZone("eu-west-1b")
+			sb.Services = append(sb.Services, remote)
+
+			for _, available := range test.LocalAvailability {
+				local := createAvailableTestService(nil, 1, time.Now())
+				local.Config.SetZone("eu-west-1a")
+				local.Available = available
+				sb.Services = append(sb.Services, local)
+			}
+
+			localElections := 0
+			remoteElections := 0
+
+			// act
+			for range 12 {
+				service, err := sb.GetAvailableService(context.Background())
+				require.NoError(t, err)
+
+				if service == remote {
+					remoteElections++
+				} else {
+					localElections++
+				}
+			}
+
+			// assert
+			assert.Equal(t, test.ExpectLocalElections, localElections > 0)
+			assert.Equal(t, test.ExpectRemoteElections, remoteElections > 0)
+		})
+	}
+}
+
+func TestServiceLocalityShouldUseDefaultLocalZone(t *testing.T) {
+	// arrange
+	logger := slog.Default()
+	cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 100, 1)
+	sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+
+	remote := createAvailableTestService(nil, 1, time.Now())
+	remote.Config.SetZone("eu-west-1b")
+	local := createAvailableTestService(nil, 1, time.Now())
+	local.Config.SetZone("eu-west-1a")
+	sb.Services = append(sb.Services, remote, local)
+
+	// act
+	sb.SetDefaultLocalZone("eu-west-1a")
+
+	// assert
+	for range 4 {
+		service, err := sb.GetAvailableService(context.Background())
+		require.NoError(t, err)
+		assert.Same(t, local, service)
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"slices"
+)
+
+// eligibleServices returns the services of the highest priority tier, lower tiers (greater Priority values)
+// are only added while the healthy capacity of the tiers above is under the failover threshold
+func (lb *ServiceBalancer) eligibleServices() []*Service {
+	if !hasSeveralPriorities(lb.Services) {
+		return lb.Services
+	}
+
+	tiers := groupServicesByPriority(lb.Services)
+
+	eligibleServices := make([]*Service, 0, len(lb.Services))
+	for _, tier := range tiers {
+		eligibleServices = append(eligibleServices, tier...)
+
+		healthyPercent, healthy := computeHealthyCapacity(tier)
+		if healthy && healthyPercent >= lb.Config.FailoverThresholdPercent {
+			break
+		}
+	}
+
+	return eligibleServices
+}
+
+func hasSeveralPriorities(services []*Service) bool {
+	for _, service := range services {
+		if service.Config.Priority != services[0].Config.Priority {
+			return true
+		}
+	}
+
+	return false
+}
+
+func groupServicesByPriority(services []*Service) [][]*Service {
+	priorities := make([]int, 0)
+	servicesByPriority := make(map[int][]*Service)
+	for _, service := range services {
+		priority := service.Config.Priority
+		if _, ok := servicesByPriority[priority]; !ok {
+			priorities = append(priorities, priority)
+		}
+
+		servicesByPriority[priority] = append(servicesByPriority[priority], service)
+	}
+
+	slices.Sort(priorities)
+
+	tiers := make([][]*Service, len(priorities))
+	for i, priority := range priorities {
+		tiers[i] = servicesByPriority[priority]
+	}
+
+	return tiers
+}
+
+func computeHealthyCapacity(services []*Service) (int, bool) {
+	totalWeight := 0
+	healthyWeight := 0
+	for _, service := range services {
+		weight := max(1, service.Config.Weight)
+		totalWeight += weight
+		if service.IsAvailable() {
+			healthyWeight += weight
+		}
+	}
+
+	if totalWeight == 0 || healthyWeight == 0 {
+		return 0, false
+	}
+
+	return healthyWeight * 100 / totalWeight, true
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestServicePriority(t *testing.T) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	testCases := []struct {
+		Name                     string
+		FailoverThresholdPercent int
+		PrimaryAvailability      []bool
+		ExpectedPrimaryElections int
+		ExpectedBackupElections  int
+	}{
+		{"should only elect primary services when they are all healthy", 100, []bool{true, true}, 6, 0},
+		{"should only elect backup services when primary services are down", 0, []bool{false, false}, 0, 6},
+		{"should keep primary services when healthy capacity is above threshold", 50, []bool{true, false}, 6, 0},
+		{"should spill to backup services when healthy capacity is under threshold", 75, []bool{true, false}, 3, 3},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			cfg := CreateRoundRobinServiceBalancerConfig(CreateDefaultHealthCheckConfig(1), 100, 1)
+			cfg.FailoverThresholdPercent = test.FailoverThresholdPercent
+			sb := CreateServiceBalancer(CreateHttpRequestForwarderFactory(logger), cfg, logger)
+
+			backup := createAvailableTestService(nil, 1, time.Now())
+			backup.Config.SetPriority(1)
+			sb.Services = append(sb.Services, backup)
+
+			primaries := make(map[*Service]bool)
+			for _, available := range test.PrimaryAvailability {
+				primary := createAvailableTestService(nil, 1, time.Now())
+				primary.Available = available
+				primaries[primary] = true
+				sb.Services = append(sb.Services, primary)
+			}
+
+			primaryElections := 0
+			backupElections := 0
+
+			// act
+			for range 6 {
+				service, err := sb.GetAvailableService(context.Background())
+				require.NoError(t, err)
+
+				if service == backup {
+					backupElections++
+				} else if primaries[service] {
+					primaryElections++
+				}
+			}
+
+			// assert
+			assert.Equal(t, test.ExpectedPrimaryElections, primaryElections)
+			assert.Equal(t, test.ExpectedBackupElections, backupElections)
+		})
+	}
+}
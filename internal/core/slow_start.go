@@ -0,0 +1,78 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+type SlowStartCurve string
+
+var (
+	LinearSlowStartCurve      SlowStartCurve = "linear"
+	ExponentialSlowStartCurve SlowStartCurve = "exponential"
+)
+
+// weights are scaled so a ramping service can receive a fraction of a weight unit,
+// strategies divide them back by their greatest common divisor
+const weightScale = 20
+const defaultSlowStartMinWeightPercent = 10
+
+type SlowStartConfig struct {
+	DurationInMs     time.Duration
+	Curve            SlowStartCurve
+	MinWeightPercent int
+}
+
+func CreateLinearSlowStartConfig(durationInMs time.Duration, minWeightPercent int) *SlowStartConfig {
+	return &SlowStartConfig{
+		DurationInMs:     durationInMs,
+		Curve:            LinearSlowStartCurve,
+		MinWeightPercent: minWeightPercent,
+	}
+}
+
+func CreateExponentialSlowStartConfig(durationInMs time.Duration, minWeightPercent int) *SlowStartConfig {
+	return &SlowStartConfig{
+		DurationInMs:     durationInMs,
+		Curve:            ExponentialSlowStartCurve,
+		MinWeightPercent: minWeightPercent,
+	}
+}
+
+func (cfg *SlowStartConfig) computeWeight(weight int, elapsed time.Duration) int {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	scaledWeight := weight * weightScale
+	if cfg == nil || cfg.DurationInMs <= 0 {
+		return scaledWeight
+	}
+
+	duration := cfg.DurationInMs * time.Millisecond
+	if elapsed >= duration {
+		return scaledWeight
+	}
+
+	progress := math.Max(0, float64(elapsed)/float64(duration))
+	minFactor := cfg.minWeightFactor()
+
+	var factor float64
+	switch cfg.Curve {
+	case ExponentialSlowStartCurve:
+		factor = minFactor * math.Pow(1/minFactor, progress)
+	default:
+		factor = minFactor + (1-minFactor)*progress
+	}
+
+	return max(1, int(math.Round(float64(scaledWeight)*factor)))
+}
+
+func (cfg *SlowStartConfig) minWeightFactor() float64 {
+	minWeightPercent := cfg.MinWeightPercent
+	if minWeightPercent <= 0 || minWeightPercent > 100 {
+		minWeightPercent = defaultSlowStartMinWeightPercent
+	}
+
+	return float64(minWeightPercent) / 100
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSlowStart(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Config         *SlowStartConfig
+		Weight         int
+		Elapsed        time.Duration
+		ExpectedWeight int
+	}{
+		{"should use full weight when slow start is disabled", nil, 2, 0, 2 * weightScale},
+		{"should use min weight when service just became available", CreateLinearSlowStartConfig(1000, 10), 5, 0, 10},
+		{"should ramp linearly to full weight", CreateLinearSlowStartConfig(1000, 10), 5, 500 * time.Millisecond, 55},
+		{"should ramp exponentially to full weight", CreateExponentialSlowStartConfig(1000, 10), 5, 500 * time.Millisecond, 32},
+		{"should use full weight once slow start window is elapsed", CreateExponentialSlowStartConfig(1000, 10), 5, time.Second, 5 * weightScale},
+		{"should never drop weight to zero", CreateLinearSlowStartConfig(1000, 1), 1, 0, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			service := CreateService(slog.Default(), CreateWeightedRoundRobinServiceConfig("localhost", 80, test.Weight))
+			service.slowStart = test.Config
+			now := time.Now()
+			service.availableSince = now.Add(-test.Elapsed)
+
+			// act
+			weight := service.effectiveWeightAt(now)
+
+			// assert
+			assert.Equal(t, test.ExpectedWeight, weight)
+		})
+	}
+
+	t.Run("should give a reduced share to a warming up service with weighted strategies", func(t *testing.T) {
+		t.Parallel()
+
+		strategies := []ServiceBalancingStrategy{&WeightedRoundRobinStrategy{}, &InterleavedRoundRobinStrategy{}}
+		for _, strategy := range strategies {
+			// arrange
+			slowStart := CreateLinearSlowStartConfig(60000, 25)
+			warmService := createAvailableTestService(slowStart, 1, time.Now().Add(-time.Hour))
+			coldService := createAvailableTestService(slowStart, 1, time.Now())
+			services := []*Service{coldService, warmService}
+			elections := make(map[*Service]int)
+
+			// act
+			for range 50 {
+				service, _ := strategy.ElectNextService(services)
+				elections[service]++
+			}
+
+			// assert
+			assert.Equal(t, 40, elections[warmService])
+			assert.Equal(t, 10, elections[coldService])
+		}
+	})
+}
+
+func TestSlowStartDistributionAcrossRamp(t *testing.T) {
+	strategies := map[string]func() ServiceBalancingStrategy{
+		"weighted round robin":    func() ServiceBalancingStrategy { return &WeightedRoundRobinStrategy{} },
+		"interleaved round robin": func() ServiceBalancingStrategy { return &InterleavedRoundRobinStrategy{} },
+	}
+
+	for name, createStrategy := range strategies {
+		t.Run("should follow the effective weights with "+name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			strategy := createStrategy()
+			slowStart := CreateLinearSlowStartConfig(60000, 10)
+			warmService := createAvailableTestService(slowStart, 2, time.Now().Add(-time.Hour))
+			coldService := createAvailableTestService(slowStart, 4, time.Now())
+			services := []*Service{warmService, coldService}
+
+			expectedColdElections := 0.0
+			coldElections := 0
+			var previousService *Service
+			consecutiveElections, consecutiveWeight, electionsBeyondWeight := 0, 0, 0
+
+			// act
+			for elapsed := time.Duration(0); elapsed < time.Minute; elapsed += 10 * time.Millisecond {
+				now := time.Now()
+				coldService.availableSince = now.Add(-elapsed)
+				coldWeight := coldService.effectiveWeightAt(now)
+				warmWeight := warmService.effectiveWeightAt(now)
+				expectedColdElections += float64(coldWeight) / float64(coldWeight+warmWeight)
+
+				service, err := strategy.ElectNextService(services)
+				require.NoError(t, err)
+
+				weight := warmWeight
+				if service == coldService {
+					weight = coldWeight
+					coldElections++
+				}
+
+				if service != previousService {
+					consecutiveElections, consecutiveWeight = 0, 0
+				}
+
+				consecutiveElections++
+				consecutiveWeight = max(consecutiveWeight, weight/gcd(coldWeight, warmWeight))
+				if consecutiveElections > consecutiveWeight {
+					electionsBeyondWeight++
+				}
+
+				previousService = service
+			}
+
+			// assert
+			assert.InDelta(t, expectedColdElections, float64(coldElections), expectedColdElections*0.02)
+			assert.Zero(t, electionsBeyondWeight)
+		})
+	}
+}
+
+func createAvailableTestService(slowStart *SlowStartConfig, weight int, availableSince time.Time) *Service {
+	service := CreateService(slog.Default(), CreateWeightedRoundRobinServiceConfig("localhost", 80, weight))
+	service.slowStart = slowStart
+	service.Available = true
+	service.availableSince = availableSince
+
+	return service
+}
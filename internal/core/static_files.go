@@ -0,0 +1,307 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var FileNotFoundErr = errors.New("file not found")
+var MethodNotAllowedErr = errors.New("method not allowed")
+
+type StaticFilesConfig struct {
+	// IndexFile is served for the directories and as the SPA fallback
+	IndexFile string
+	// SpaFallback serves the index file of the root for the missing files requested by a browser navigation
+	SpaFallback bool
+	// DirectoryListing lists the content of the directories without index file, they are not found otherwise
+	DirectoryListing bool
+	// Precompressed serves the .br or .gz sibling of a file when the client accepts its encoding
+	Precompressed bool
+}
+
+func CreateStaticFilesConfig() *StaticFilesConfig {
+	return &StaticFilesConfig{
+		IndexFile:     "index.html",
+		Precompressed: true,
+	}
+}
+
+type precompressedEncoding struct {
+	name      string
+	extension string
+}
+
+// precompressedEncodings are ordered by preference
+var precompressedEncodings = []precompressedEncoding{
+	{name: "br", extension: ".br"},
+	{name: "gzip", extension: ".gz"},
+}
+
+type StaticFilesHandler struct {
+	fsys   fs.FS
+	config *StaticFilesConfig
+	etags  sync.Map
+}
+
+type cachedEtag struct {
+	size int64
+	etag string
+}
+
+// CreateStaticFilesHandler serves the files of fsys, such as an os.DirFS or an embed.FS, at the path of the request,
+// a path prefix is not stripped, conditional and Range requests are handled by http.ServeContent
+func CreateStaticFilesHandler(fsys fs.FS, config *StaticFilesConfig) *StaticFilesHandler {
+	if config == nil {
+		config = CreateStaticFilesConfig()
+	}
+
+	return &StaticFilesHandler{
+		fsys:   fsys,
+		config: config,
+	}
+}
+
+func (h *StaticFilesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		_ = WriteError(w, r, MethodNotAllowedErr)
+		return
+	}
+
+	name := staticFileName(r.URL.Path)
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		if h.acceptsSpaFallback(r) {
+			w.Header().Set("Cache-Control", "no-cache")
+			h.serveFile(w, r, h.config.IndexFile)
+			return
+		}
+
+		_ = WriteError(w, r, FileNotFoundErr)
+		return
+	}
+
+	if !info.IsDir() {
+		h.serveFile(w, r, name)
+		return
+	}
+
+	// the links of the directory are relative to it, it must be requested with a trailing slash
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		redirectToDirectory(w, r)
+		return
+	}
+
+	index := path.Join(name, h.config.IndexFile)
+	if indexInfo, err := fs.Stat(h.fsys, index); err == nil && !indexInfo.IsDir() {
+		h.serveFile(w, r, index)
+		return
+	}
+
+	if !h.config.DirectoryListing {
+		_ = WriteError(w, r, FileNotFoundErr)
+		return
+	}
+
+	h.serveDirectoryListing(w, r, name)
+}
+
+// staticFileName returns the name of the file in the fs.FS, the cleaned path cannot escape its root
+func staticFileName(urlPath string) string {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+func redirectToDirectory(w http.ResponseWriter, r *http.Request) {
+	target := path.Base(r.URL.Path) + "/"
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// acceptsSpaFallback only falls back for the navigations of a browser, missing assets stay not found
+func (h *StaticFilesHandler) acceptsSpaFallback(r *http.Request) bool {
+	return h.config.SpaFallback && h.config.IndexFile != "" && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (h *StaticFilesHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	servedName := name
+	encoding, compressed := h.precompressedSibling(r, name)
+	if compressed {
+		servedName = name + encoding.extension
+	}
+
+	file, err := h.fsys.Open(servedName)
+	if err != nil {
+		_ = WriteError(w, r, FileNotFoundErr)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		_ = WriteError(w, r, FileNotFoundErr)
+		return
+	}
+
+	content, err := readSeeker(file)
+	if err != nil {
+		_ = WriteError(w, r, err)
+		return
+	}
+
+	etag, err := h.etag(servedName, info, content)
+	if err != nil {
+		_ = WriteError(w, r, err)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if h.config.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if compressed {
+		w.Header().Set("Content-Encoding", encoding.name)
+
+		// the content of the sibling must not be sniffed to detect the type of the original file
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+func (h *StaticFilesHandler) precompressedSibling(r *http.Request, name string) (precompressedEncoding, bool) {
+	if !h.config.Precompressed {
+		return precompressedEncoding{}, false
+	}
+
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+	for _, encoding := range precompressedEncodings {
+		if !acceptsEncoding(acceptEncoding, encoding.name) {
+			continue
+		}
+
+		if info, err := fs.Stat(h.fsys, name+encoding.extension); err == nil && !info.IsDir() {
+			return encoding, true
+		}
+	}
+
+	return precompressedEncoding{}, false
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header lists the encoding, or *, without a zero quality
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	for _, value := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(value, ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, encoding) && name != "*" {
+			continue
+		}
+
+		quality, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+
+		q, err := strconv.ParseFloat(quality, 64)
+		return err == nil && q > 0
+	}
+
+	return false
+}
+
+// readSeeker returns the file itself when it can seek, as the files of os.DirFS and embed.FS, its content otherwise
+func readSeeker(file fs.File) (io.ReadSeeker, error) {
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		return seeker, nil
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(content), nil
+}
+
+// etag derives the entity tag from the modification time and the size of the file,
+// the files without modification time, as the ones of embed.FS, are hashed once instead
+func (h *StaticFilesHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	if cached, ok := h.etags.Load(name); ok {
+		if cached := cached.(*cachedEtag); cached.size == info.Size() {
+			return cached.etag, nil
+		}
+	}
+
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := fmt.Sprintf(`"%x"`, hash.Sum64())
+	h.etags.Store(name, &cachedEtag{size: info.Size(), etag: etag})
+
+	return etag, nil
+}
+
+func (h *StaticFilesHandler) serveDirectoryListing(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(h.fsys, name)
+	if err != nil {
+		_ = WriteError(w, r, FileNotFoundErr)
+		return
+	}
+
+	var listing strings.Builder
+	listing.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+
+		link := url.URL{Path: entryName}
+		fmt.Fprintf(&listing, "<a href=\"%s\">%s</a>\n", html.EscapeString(link.String()), html.EscapeString(entryName))
+	}
+	listing.WriteString("</pre>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = io.WriteString(w, listing.String())
+	}
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testStaticFilesModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func createTestStaticFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":          {Data: []byte("<html>app</html>"), ModTime: testStaticFilesModTime},
+		"assets/app.js":       {Data: []byte("console.log('app')"), ModTime: testStaticFilesModTime},
+		"assets/app.js.gz":    {Data: []byte("gzip content"), ModTime: testStaticFilesModTime},
+		"assets/app.js.br":    {Data: []byte("brotli content"), ModTime: testStaticFilesModTime},
+		"assets/data.txt":     {Data: []byte("0123456789")},
+		"docs/readme.md":      {Data: []byte("# readme"), ModTime: testStaticFilesModTime},
+		"docs/guide/intro.md": {Data: []byte("# intro"), ModTime: testStaticFilesModTime},
+	}
+}
+
+func TestStaticFilesHandler(t *testing.T) {
+	testCases := []struct {
+		Name, Method, Url string
+		Headers           map[string]string
+		Config            *StaticFilesConfig
+		ExpectedStatus    int
+		ExpectedBody      string
+		ExpectedHeaders   map[string]string
+	}{
+		{
+			Name:            "should serve file with its content type",
+			Url:             "http://localhost/assets/app.js",
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "console.log('app')",
+			ExpectedHeaders: map[string]string{"Content-Type": "text/javascript; charset=utf-8", "Last-Modified": "Tue, 02 Jan 2024 03:04:05 GMT", "Vary": "Accept-Encoding"},
+		},
+		{
+			Name:            "should serve brotli sibling when accepted",
+			Url:             "http://localhost/assets/app.js",
+			Headers:         map[string]string{"Accept-Encoding": "gzip, br"},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "brotli content",
+			ExpectedHeaders: map[string]string{"Content-Encoding": "br", "Content-Type": "text/javascript; charset=utf-8"},
+		},
+		{
+			Name:            "should serve gzip sibling when brotli is refused",
+			Url:             "http://localhost/assets/app.js",
+			Headers:         map[string]string{"Accept-Encoding": "gzip, br;q=0"},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "gzip content",
+			ExpectedHeaders: map[string]string{"Content-Encoding": "gzip"},
+		},
+		{
+			Name:            "should not serve siblings when precompressed files are disabled",
+			Url:             "http://localhost/assets/app.js",
+			Headers:         map[string]string{"Accept-Encoding": "gzip, br"},
+			Config:          &StaticFilesConfig{IndexFile: "index.html"},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "console.log('app')",
+			ExpectedHeaders: map[string]string{"Content-Encoding": "", "Vary": ""},
+		},
+		{
+			Name:            "should serve requested range",
+			Url:             "http://localhost/assets/data.txt",
+			Headers:         map[string]string{"Range": "bytes=2-5"},
+			ExpectedStatus:  http.StatusPartialContent,
+			ExpectedBody:    "2345",
+			ExpectedHeaders: map[string]string{"Content-Range": "bytes 2-5/10"},
+		},
+		{
+			Name:           "should not modify file matching If-Modified-Since",
+			Url:            "http://localhost/assets/app.js",
+			Headers:        map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"},
+			ExpectedStatus: http.StatusNotModified,
+		},
+		{
+			Name:           "should not serve HEAD body",
+			Method:         http.MethodHead,
+			Url:            "http://localhost/assets/app.js",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:            "should serve index of directory",
+			Url:             "http://localhost/",
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "<html>app</html>",
+			ExpectedHeaders: map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		},
+		{
+			Name:            "should redirect directory without trailing slash",
+			Url:             "http://localhost/docs?lang=en",
+			ExpectedStatus:  http.StatusMovedPermanently,
+			ExpectedHeaders: map[string]string{"Location": "docs/?lang=en"},
+		},
+		{
+			Name:           "should not list directory by default",
+			Url:            "http://localhost/docs/",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should list directory when enabled",
+			Url:            "http://localhost/docs/",
+			Config:         &StaticFilesConfig{IndexFile: "index.html", DirectoryListing: true},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"guide/\">guide/</a>\n<a href=\"readme.md\">readme.md</a>\n</pre>\n",
+		},
+		{
+			Name:           "should not find missing file",
+			Url:            "http://localhost/assets/missing.js",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:            "should fall back to index for browser navigation",
+			Url:             "http://localhost/users/42",
+			Headers:         map[string]string{"Accept": "text/html,application/xhtml+xml"},
+			Config:          &StaticFilesConfig{IndexFile: "index.html", SpaFallback: true},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "<html>app</html>",
+			ExpectedHeaders: map[string]string{"Cache-Control": "no-cache"},
+		},
+		{
+			Name:           "should not fall back to index for missing asset",
+			Url:            "http://localhost/assets/missing.js",
+			Headers:        map[string]string{"Accept": "*/*"},
+			Config:         &StaticFilesConfig{IndexFile: "index.html", SpaFallback: true},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should not escape root",
+			Url:            "http://localhost/assets/../../../etc/passwd",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:            "should reject other methods",
+			Method:          http.MethodPost,
+			Url:             "http://localhost/assets/app.js",
+			ExpectedStatus:  http.StatusMethodNotAllowed,
+			ExpectedHeaders: map[string]string{"Allow": "GET, HEAD"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			handler := CreateStaticFilesHandler(createTestStaticFiles(), test.Config)
+
+			method := test.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			request := httptest.NewRequest(method, test.Url, nil)
+			for name, value := range test.Headers {
+				request.Header.Set(name, value)
+			}
+
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+			if test.ExpectedBody != "" || test.Method == http.MethodHead {
+				assert.Equal(t, test.ExpectedBody, response.Body.String())
+			}
+
+			for name, value := range test.ExpectedHeaders {
+				assert.Equal(t, value, response.Header().Get(name), name)
+			}
+		})
+	}
+}
+
+func TestStaticFilesHandlerEtag(t *testing.T) {
+	for _, name := range []string{"assets/app.js", "assets/data.txt"} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			handler := CreateStaticFilesHandler(createTestStaticFiles(), nil)
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://localhost/"+name, nil))
+			etag := response.Header().Get("ETag")
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/"+name, nil)
+			request.Header.Set("If-None-Match", etag)
+			conditionalResponse := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(conditionalResponse, request)
+
+			// assert
+			assert.NotEmpty(t, etag)
+			assert.Equal(t, http.StatusNotModified, conditionalResponse.Code)
+		})
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	assert.True(t, acceptsEncoding("gzip, deflate", "gzip"))
+	assert.True(t, acceptsEncoding("*", "br"))
+	assert.True(t, acceptsEncoding("br;q=0.5", "br"))
+	assert.False(t, acceptsEncoding("br;q=0", "br"))
+	assert.False(t, acceptsEncoding("gzip", "br"))
+	assert.False(t, acceptsEncoding("", "gzip"))
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+var redirectStatuses = []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect}
+
+type RedirectHandler struct {
+	status int
+	target *requestTemplate
+}
+
+// CreateRedirectHandler redirects to the target rendered as a request template, such as https://{host}{request_uri}
+func CreateRedirectHandler(status int, targetTemplate string) (*RedirectHandler, error) {
+	if !slices.Contains(redirectStatuses, status) {
+		return nil, fmt.Errorf("redirect status must be one of 301, 302, 307 or 308, got %d", status)
+	}
+
+	target, err := parseRequestTemplate(targetTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect target: %w", err)
+	}
+
+	return &RedirectHandler{
+		status: status,
+		target: target,
+	}, nil
+}
+
+func (h *RedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, h.target.render(r), h.status)
+}
+
+type DirectResponseHandler struct {
+	status  int
+	body    []byte
+	headers http.Header
+}
+
+// CreateDirectResponseHandler answers with a fixed status, body and headers, text/plain is used when a body has no Content-Type
+func CreateDirectResponseHandler(status int, body string, headers http.Header) *DirectResponseHandler {
+	headers = headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+
+	if body != "" && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	return &DirectResponseHandler{
+		status:  status,
+		body:    []byte(body),
+		headers: headers,
+	}
+}
+
+func (h *DirectResponseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for name, values := range h.headers {
+		w.Header()[name] = slices.Clone(values)
+	}
+
+	w.WriteHeader(h.status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(h.body)
+	}
+}
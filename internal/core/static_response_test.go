@@ -0,0 +1,66 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	testCases := []struct {
+		Name, Target, Url, ExpectedLocation string
+		Status                              int
+	}{
+		{"should redirect to https", "https://{host}{request_uri}", "http://example.com:8080/users?page=2", "https://example.com/users?page=2", http.StatusMovedPermanently},
+		{"should redirect to canonical host", "{scheme}://www.{host}{path}", "http://example.com/users", "http://www.example.com/users", http.StatusPermanentRedirect},
+		{"should redirect with path params", "/v2/accounts/{param.id}", "http://example.com/v1/users/42", "/v2/accounts/42", http.StatusFound},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			handler, err := CreateRedirectHandler(test.Status, test.Target)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
+			request = request.WithContext(WithPathParams(request.Context(), map[string]string{"id": "42"}))
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.Status, response.Code)
+			assert.Equal(t, test.ExpectedLocation, response.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandlerCreation(t *testing.T) {
+	_, err := CreateRedirectHandler(http.StatusOK, "/")
+	assert.Error(t, err)
+
+	_, err = CreateRedirectHandler(http.StatusFound, "https://{unknown}/")
+	assert.Error(t, err)
+}
+
+func TestDirectResponseHandler(t *testing.T) {
+	// arrange
+	handler := CreateDirectResponseHandler(http.StatusServiceUnavailable, "down for maintenance", http.Header{"Retry-After": {"120"}})
+	response := httptest.NewRecorder()
+	headResponse := httptest.NewRecorder()
+
+	// act
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	handler.ServeHTTP(headResponse, httptest.NewRequest(http.MethodHead, "http://example.com/", nil))
+
+	// assert
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, "down for maintenance", response.Body.String())
+	assert.Equal(t, "120", response.Header().Get("Retry-After"))
+	assert.Equal(t, "text/plain; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusServiceUnavailable, headResponse.Code)
+	assert.Empty(t, headResponse.Body.String())
+}
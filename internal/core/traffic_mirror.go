@@ -0,0 +1,152 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type TrafficMirrorConfig struct {
+	Percent               int
+	MaxBodyBytes          int64
+	MaxConcurrentRequests int
+	TimeoutInMs           time.Duration
+}
+
+func CreateTrafficMirrorConfig(percent int) *TrafficMirrorConfig {
+	return &TrafficMirrorConfig{
+		Percent:               percent,
+		MaxBodyBytes:          64 * 1024,
+		MaxConcurrentRequests: 10,
+		TimeoutInMs:           1000,
+	}
+}
+
+// TrafficMirror sends a copy of a percentage of the requests to a shadow balancer and discards its responses,
+// requests are not mirrored when their body is larger than MaxBodyBytes or when MaxConcurrentRequests are in flight
+type TrafficMirror struct {
+	logger   *slog.Logger
+	shadow   UpstreamHandler
+	config   *TrafficMirrorConfig
+	slots    chan struct{}
+	requests atomic.Uint64
+	inFlight sync.WaitGroup
+}
+
+func CreateTrafficMirror(shadow UpstreamHandler, config *TrafficMirrorConfig, logger *slog.Logger) *TrafficMirror {
+	return &TrafficMirror{
+		logger: logger,
+		shadow: shadow,
+		config: config,
+		slots:  make(chan struct{}, max(config.MaxConcurrentRequests, 1)),
+	}
+}
+
+func (m *TrafficMirror) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if m.shouldMirror() {
+				m.mirror(r)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Wait blocks until the mirrored requests in flight are completed
+func (m *TrafficMirror) Wait() {
+	m.inFlight.Wait()
+}
+
+// shouldMirror spreads the mirrored requests evenly, mirroring exactly Percent requests out of 100
+func (m *TrafficMirror) shouldMirror() bool {
+	percent := uint64(min(max(m.config.Percent, 0), 100))
+	count := m.requests.Add(1)
+	return count*percent/100 != (count-1)*percent/100
+}
+
+func (m *TrafficMirror) mirror(r *http.Request) {
+	ctx := r.Context()
+	if r.ContentLength > m.config.MaxBodyBytes {
+		m.logger.Log(ctx, slog.LevelDebug, "request body too large to be mirrored", slog.Int64("content_length", r.ContentLength))
+		return
+	}
+
+	body, err := m.bufferBody(r)
+	if err != nil {
+		m.logger.Log(ctx, slog.LevelDebug, "request not mirrored", slog.Any("error", err))
+		return
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		m.logger.Log(ctx, slog.LevelDebug, "too many mirrored requests in flight, request not mirrored")
+		return
+	}
+
+	shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.config.TimeoutInMs*time.Millisecond)
+	shadowRequest := r.Clone(shadowCtx)
+	shadowRequest.Body = io.NopCloser(bytes.NewReader(body))
+
+	m.inFlight.Add(1)
+	go func() {
+		defer m.inFlight.Done()
+		defer func() { <-m.slots }()
+		defer cancel()
+
+		m.send(shadowRequest)
+	}()
+}
+
+var mirroredBodyTooLargeErr = errors.New("request body too large to be mirrored")
+
+// bufferBody reads the body up to MaxBodyBytes and restores it for the primary request whatever the outcome
+func (m *TrafficMirror) bufferBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.config.MaxBodyBytes+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > m.config.MaxBodyBytes {
+		return nil, mirroredBodyTooLargeErr
+	}
+
+	return body, nil
+}
+
+func (m *TrafficMirror) send(shadowRequest *http.Request) {
+	ctx := shadowRequest.Context()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			m.logger.Log(ctx, slog.LevelError, "recovered from panic while mirroring request", slog.Any("panic", recovered))
+		}
+	}()
+
+	resp, err := m.shadow.HandleRequest(ctx, shadowRequest)
+	if err != nil {
+		m.logger.Log(ctx, slog.LevelDebug, "mirrored request failed", slog.Any("error", err), slog.String("request_id", RequestIdFromContext(ctx)))
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type upstreamHandlerFunc func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+func (f upstreamHandlerFunc) HandleRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+func TestTrafficMirror(t *testing.T) {
+	logger := slog.Default()
+	slog.SetLogLoggerLevel(slog.LevelError)
+
+	t.Run("should mirror the configured percentage of requests with their body", func(t *testing.T) {
+		// arrange
+		var mutex sync.Mutex
+		mirroredBodies := make([]string, 0)
+		shadow := upstreamHandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			mutex.Lock()
+			mirroredBodies = append(mirroredBodies, string(body))
+			mutex.Unlock()
+			return httptest.NewRecorder().Result(), nil
+		})
+
+		mirror := CreateTrafficMirror(shadow, CreateTrafficMirrorConfig(10), logger)
+		primaryBodies := make([]string, 0)
+		handler := mirror.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			primaryBodies = append(primaryBodies, string(body))
+		}))
+
+		// act
+		for range 100 {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("payload")))
+		}
+		mirror.Wait()
+
+		// assert
+		assert.Len(t, mirroredBodies, 10)
+		assert.Equal(t, "payload", mirroredBodies[0])
+		assert.Len(t, primaryBodies, 100)
+		assert.Equal(t, "payload", primaryBodies[0])
+	})
+
+	t.Run("should not mirror request with body larger than the buffer", func(t *testing.T) {
+		// arrange
+		mirrored := false
+		shadow := upstreamHandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			mirrored = true
+			return httptest.NewRecorder().Result(), nil
+		})
+
+		cfg := CreateTrafficMirrorConfig(100)
+		cfg.MaxBodyBytes = 4
+		mirror := CreateTrafficMirror(shadow, cfg, logger)
+
+		var primaryBody string
+		handler := mirror.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			primaryBody = string(body)
+		}))
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost/", io.NopCloser(strings.NewReader("large payload")))
+		request.ContentLength = -1
+
+		// act
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		mirror.Wait()
+
+		// assert
+		assert.False(t, mirrored)
+		assert.Equal(t, "large payload", primaryBody)
+	})
+
+	t.Run("should not mirror more requests than the concurrency limit", func(t *testing.T) {
+		// arrange
+		release := make(chan struct{})
+		var mutex sync.Mutex
+		mirroredCount := 0
+		shadow := upstreamHandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			mirroredCount++
+			mutex.Unlock()
+			<-release
+			return httptest.NewRecorder().Result(), nil
+		})
+
+		cfg := CreateTrafficMirrorConfig(100)
+		cfg.MaxConcurrentRequests = 2
+		mirror := CreateTrafficMirror(shadow, cfg, logger)
+		handler := mirror.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		// act
+		for range 5 {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+		}
+		close(release)
+		mirror.Wait()
+
+		// assert
+		assert.Equal(t, 2, mirroredCount)
+	})
+
+	t.Run("should not affect primary response when shadow fails", func(t *testing.T) {
+		for _, shadow := range []upstreamHandlerFunc{
+			func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return nil, errors.New("shadow down")
+			},
+			func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				panic("shadow panic")
+			},
+		} {
+			// arrange
+			mirror := CreateTrafficMirror(shadow, CreateTrafficMirrorConfig(100), logger)
+			handler := mirror.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			response := httptest.NewRecorder()
+
+			// act
+			handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+			mirror.Wait()
+
+			// assert
+			assert.Equal(t, http.StatusAccepted, response.Code)
+		}
+	})
+
+	t.Run("should apply its own timeout independently of the primary request", func(t *testing.T) {
+		// arrange
+		var shadowErr error
+		shadow := upstreamHandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			<-ctx.Done()
+			shadowErr = ctx.Err()
+			return nil, ctx.Err()
+		})
+
+		cfg := CreateTrafficMirrorConfig(100)
+		cfg.TimeoutInMs = 20
+		mirror := CreateTrafficMirror(shadow, cfg, logger)
+		handler := mirror.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		primaryCtx, cancelPrimary := context.WithCancel(context.Background())
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil).WithContext(primaryCtx)
+
+		// act
+		start := time.Now()
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		cancelPrimary()
+		mirror.Wait()
+
+		// assert
+		require.ErrorIs(t, shadowErr, context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+}
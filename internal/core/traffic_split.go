@@ -0,0 +1,205 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// TrafficSplitStickiness keeps a client on the same backend,
+// CookieName issues a cookie naming the elected backend while HeaderName hashes the header value into the weights
+type TrafficSplitStickiness struct {
+	CookieName string
+	HeaderName string
+}
+
+// TrafficSplit splits the requests of an application across several balancers proportionally to their weights
+type TrafficSplit struct {
+	logger     *slog.Logger
+	mutex      sync.Mutex
+	backends   []*splitBackend
+	stickiness *TrafficSplitStickiness
+}
+
+type splitBackend struct {
+	name          string
+	balancer      *ServiceBalancer
+	weight        int
+	currentWeight int
+}
+
+func CreateTrafficSplit(stickiness *TrafficSplitStickiness, logger *slog.Logger) *TrafficSplit {
+	return &TrafficSplit{
+		logger:     logger,
+		backends:   make([]*splitBackend, 0),
+		stickiness: stickiness,
+	}
+}
+
+func (t *TrafficSplit) AddBackend(name string, balancer *ServiceBalancer, weight int) error {
+	if weight < 0 {
+		return fmt.Errorf("weight of backend %q cannot be negative", name)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.findBackend(name) != nil {
+		return fmt.Errorf("backend %q already exists", name)
+	}
+
+	t.backends = append(t.backends, &splitBackend{name: name, balancer: balancer, weight: weight})
+	return nil
+}
+
+// SetWeights updates the weights of the named backends at once so a rollout step never exposes a partial split
+func (t *TrafficSplit) SetWeights(weights map[string]int) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for name, weight := range weights {
+		if t.findBackend(name) == nil {
+			return fmt.Errorf("backend %q not found", name)
+		}
+
+		if weight < 0 {
+			return fmt.Errorf("weight of backend %q cannot be negative", name)
+		}
+	}
+
+	for _, backend := range t.backends {
+		if weight, ok := weights[backend.name]; ok {
+			backend.weight = weight
+		}
+
+		backend.currentWeight = 0
+	}
+
+	return nil
+}
+
+func (t *TrafficSplit) Weights() map[string]int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	weights := make(map[string]int, len(t.backends))
+	for _, backend := range t.backends {
+		weights[backend.name] = backend.weight
+	}
+
+	return weights
+}
+
+func (t *TrafficSplit) Balancers() []*ServiceBalancer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	balancers := make([]*ServiceBalancer, len(t.backends))
+	for i, backend := range t.backends {
+		balancers[i] = backend.balancer
+	}
+
+	return balancers
+}
+
+func (t *TrafficSplit) HandleRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	backend, issueCookie, err := t.selectBackend(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.logger.Log(ctx, slog.LevelDebug, "splitting request to backend", slog.String("backend_name", backend.name))
+
+	resp, err := backend.balancer.HandleRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if issueCookie {
+		cookie := &http.Cookie{Name: t.stickiness.CookieName, Value: backend.name, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+		resp.Header.Add("Set-Cookie", cookie.String())
+	}
+
+	return resp, nil
+}
+
+// selectBackend returns the elected backend and whether the sticky cookie must be issued
+func (t *TrafficSplit) selectBackend(req *http.Request) (*splitBackend, bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	totalWeight := 0
+	for _, backend := range t.backends {
+		totalWeight += backend.weight
+	}
+
+	if totalWeight == 0 {
+		return nil, false, fmt.Errorf("no backend with a positive weight: %w", ServiceUnavailableErr)
+	}
+
+	if t.stickiness == nil {
+		return t.nextWeightedBackend(totalWeight), false, nil
+	}
+
+	if t.stickiness.HeaderName != "" {
+		if value := req.Header.Get(t.stickiness.HeaderName); value != "" {
+			return t.hashedBackend(value, totalWeight), false, nil
+		}
+	}
+
+	if t.stickiness.CookieName == "" {
+		return t.nextWeightedBackend(totalWeight), false, nil
+	}
+
+	if cookie, err := req.Cookie(t.stickiness.CookieName); err == nil {
+		if backend := t.findBackend(cookie.Value); backend != nil && backend.weight > 0 {
+			return backend, false, nil
+		}
+	}
+
+	return t.nextWeightedBackend(totalWeight), true, nil
+}
+
+// nextWeightedBackend uses a smooth weighted round-robin so the split is exact over totalWeight requests
+func (t *TrafficSplit) nextWeightedBackend(totalWeight int) *splitBackend {
+	var elected *splitBackend
+	for _, backend := range t.backends {
+		backend.currentWeight += backend.weight
+		if elected == nil || backend.currentWeight > elected.currentWeight {
+			elected = backend
+		}
+	}
+
+	elected.currentWeight -= totalWeight
+	return elected
+}
+
+// hashedBackend maps the value on the cumulated weights, raising the weight of the last backend only moves clients toward it
+func (t *TrafficSplit) hashedBackend(value string, totalWeight int) *splitBackend {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(value))
+	bucket := int(hash.Sum64() % uint64(totalWeight))
+
+	for _, backend := range t.backends {
+		if bucket < backend.weight {
+			return backend
+		}
+
+		bucket -= backend.weight
+	}
+
+	return t.backends[len(t.backends)-1]
+}
+
+func (t *TrafficSplit) findBackend(name string) *splitBackend {
+	for _, backend := range t.backends {
+		if backend.name == name {
+			return backend
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestTrafficSplit(t *testing.T) {
+	logger := slog.Default()
+
+	t.Run("should split requests proportionally to weights", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(nil, logger, 95, 5)
+		elections := make(map[string]int)
+
+		// act
+		for range 100 {
+			backend, _, err := split.selectBackend(httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+			require.NoError(t, err)
+			elections[backend.name]++
+		}
+
+		// assert
+		assert.Equal(t, map[string]int{"v1": 95, "v2": 5}, elections)
+	})
+
+	t.Run("should apply updated weights", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(nil, logger, 95, 5)
+		require.NoError(t, split.SetWeights(map[string]int{"v1": 0, "v2": 100}))
+
+		// act
+		backend, _, err := split.selectBackend(httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, "v2", backend.name)
+		assert.Equal(t, map[string]int{"v1": 0, "v2": 100}, split.Weights())
+	})
+
+	t.Run("should reject invalid weights without applying any", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(nil, logger, 95, 5)
+
+		// act
+		unknownErr := split.SetWeights(map[string]int{"v1": 50, "v3": 50})
+		negativeErr := split.SetWeights(map[string]int{"v1": 50, "v2": -1})
+
+		// assert
+		assert.Error(t, unknownErr)
+		assert.Error(t, negativeErr)
+		assert.Equal(t, map[string]int{"v1": 95, "v2": 5}, split.Weights())
+	})
+
+	t.Run("should be unavailable when every weight is zero", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(nil, logger, 0, 0)
+
+		// act
+		_, _, err := split.selectBackend(httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+		// assert
+		assert.ErrorIs(t, err, ServiceUnavailableErr)
+	})
+
+	t.Run("should keep clients on the same backend by header hash", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(&TrafficSplitStickiness{HeaderName: "X-User-Id"}, logger, 50, 50)
+		elections := make(map[string]int)
+
+		// act
+		for user := range 100 {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.Header.Set("X-User-Id", strconv.Itoa(user))
+
+			first, _, _ := split.selectBackend(request)
+			second, _, _ := split.selectBackend(request)
+			require.Equal(t, first, second)
+			elections[first.name]++
+		}
+
+		// assert
+		assert.Greater(t, elections["v1"], 0)
+		assert.Greater(t, elections["v2"], 0)
+	})
+
+	t.Run("should only move hashed clients toward the raised backend", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(&TrafficSplitStickiness{HeaderName: "X-User-Id"}, logger, 95, 5)
+		canaryUsers := make([]*http.Request, 0)
+		for user := range 200 {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.Header.Set("X-User-Id", strconv.Itoa(user))
+
+			if backend, _, _ := split.selectBackend(request); backend.name == "v2" {
+				canaryUsers = append(canaryUsers, request)
+			}
+		}
+
+		// act
+		require.NoError(t, split.SetWeights(map[string]int{"v1": 50, "v2": 50}))
+
+		// assert
+		require.NotEmpty(t, canaryUsers)
+		for _, request := range canaryUsers {
+			backend, _, _ := split.selectBackend(request)
+			assert.Equal(t, "v2", backend.name)
+		}
+	})
+
+	t.Run("should issue sticky cookie and honor it", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(&TrafficSplitStickiness{CookieName: "version"}, logger, 50, 50)
+
+		// act
+		backend, issueCookie, _ := split.selectBackend(httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+		stickyRequest := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		stickyRequest.AddCookie(&http.Cookie{Name: "version", Value: "v2"})
+		stickyBackend, reissueCookie, _ := split.selectBackend(stickyRequest)
+
+		// assert
+		assert.NotNil(t, backend)
+		assert.True(t, issueCookie)
+		assert.Equal(t, "v2", stickyBackend.name)
+		assert.False(t, reissueCookie)
+	})
+
+	t.Run("should reissue sticky cookie when its backend has no weight", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(&TrafficSplitStickiness{CookieName: "version"}, logger, 100, 0)
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		request.AddCookie(&http.Cookie{Name: "version", Value: "v2"})
+
+		// act
+		backend, issueCookie, _ := split.selectBackend(request)
+
+		// assert
+		assert.Equal(t, "v1", backend.name)
+		assert.True(t, issueCookie)
+	})
+
+	t.Run("should reject duplicated backend", func(t *testing.T) {
+		// arrange
+		split := createTestTrafficSplit(nil, logger, 95, 5)
+
+		// act
+		err := split.AddBackend("v1", nil, 1)
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func createTestTrafficSplit(stickiness *TrafficSplitStickiness, logger *slog.Logger, weights ...int) *TrafficSplit {
+	split := CreateTrafficSplit(stickiness, logger)
+	for i, weight := range weights {
+		_ = split.AddBackend("v"+strconv.Itoa(i+1), nil, weight)
+	}
+
+	return split
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// rewriteUpstreamLocations points the Location and Content-Location headers targeting the upstream back to the public host,
+// paths are prefixed with the prefix removed by the path rewrite rules like nginx proxy_redirect
+func rewriteUpstreamLocations(resp *http.Response, r *http.Request) {
+	for _, headerName := range []string{"Location", "Content-Location"} {
+		values, ok := resp.Header[headerName]
+		if !ok {
+			continue
+		}
+
+		for i, value := range values {
+			values[i] = rewriteUpstreamLocation(value, resp.Request, r)
+		}
+	}
+}
+
+func rewriteUpstreamLocation(location string, upstreamRequest *http.Request, r *http.Request) string {
+	target, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	if target.IsAbs() {
+		if upstreamRequest == nil || !isUpstreamHost(target.Host, upstreamRequest) || strings.EqualFold(target.Host, r.Host) {
+			return location
+		}
+
+		target.Scheme = requestScheme(r)
+		target.Host = r.Host
+	} else if target.Host != "" || !strings.HasPrefix(target.Path, "/") {
+		return location
+	}
+
+	if prefix := ForwardedPrefixFromContext(r.Context()); prefix != "" {
+		target.Path = prefix + target.Path
+		if target.RawPath != "" {
+			target.RawPath = prefix + target.RawPath
+		}
+	}
+
+	return target.String()
+}
+
+// rewriteUpstreamCookie moves the cookies scoped to the upstream host to the public host and under the removed path prefix,
+// like nginx proxy_cookie_domain and proxy_cookie_path
+func rewriteUpstreamCookie(cookie *http.Cookie, upstreamRequest *http.Request, r *http.Request) {
+	if cookie.Domain != "" && upstreamRequest != nil && isUpstreamHost(strings.TrimPrefix(cookie.Domain, "."), upstreamRequest) {
+		cookie.Domain = hostWithoutPort(r.Host)
+	}
+
+	if prefix := ForwardedPrefixFromContext(r.Context()); prefix != "" && strings.HasPrefix(cookie.Path, "/") {
+		cookie.Path = prefix + cookie.Path
+	}
+}
+
+// isUpstreamHost reports whether host designates the upstream, by its address or by the Host header it received
+func isUpstreamHost(host string, upstreamRequest *http.Request) bool {
+	for _, upstreamHost := range []string{upstreamRequest.URL.Host, upstreamRequest.Host} {
+		if upstreamHost == "" {
+			continue
+		}
+
+		if strings.EqualFold(host, upstreamHost) || strings.EqualFold(host, hostWithoutPort(upstreamHost)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hostWithoutPort(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+
+	return host
+}
+
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
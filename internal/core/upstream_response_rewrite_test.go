@@ -0,0 +1,75 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRewriteUpstreamLocation(t *testing.T) {
+	testCases := []struct {
+		Name, Location, Prefix, ExpectedLocation string
+	}{
+		{"should rewrite upstream address to public host", "http://10.0.0.5:8080/login?next=%2F", "", "http://public.example.com/login?next=%2F"},
+		{"should rewrite upstream host header to public host", "http://backend.internal/login", "", "http://public.example.com/login"},
+		{"should rewrite upstream address under removed prefix", "http://10.0.0.5:8080/login", "/service-a", "http://public.example.com/service-a/login"},
+		{"should prefix absolute path under removed prefix", "/login", "/service-a", "/service-a/login"},
+		{"should keep relative path", "login", "/service-a", "login"},
+		{"should keep external location", "https://accounts.example.org/authorize", "/service-a", "https://accounts.example.org/authorize"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			request := httptest.NewRequest(http.MethodGet, "http://public.example.com/service-a/users", nil)
+			if test.Prefix != "" {
+				request = request.WithContext(WithForwardedPrefix(request.Context(), test.Prefix))
+			}
+
+			upstreamRequest := httptest.NewRequest(http.MethodGet, "http://10.0.0.5:8080/users", nil)
+			upstreamRequest.Host = "backend.internal"
+
+			// act
+			location := rewriteUpstreamLocation(test.Location, upstreamRequest, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedLocation, location)
+		})
+	}
+}
+
+func TestRewriteUpstreamCookie(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Cookie         *http.Cookie
+		Prefix         string
+		ExpectedDomain string
+		ExpectedPath   string
+	}{
+		{"should rewrite upstream domain to public host", &http.Cookie{Name: "a", Domain: "10.0.0.5"}, "", "public.example.com", ""},
+		{"should rewrite upstream host header domain to public host", &http.Cookie{Name: "a", Domain: ".backend.internal", Path: "/"}, "/service-a", "public.example.com", "/service-a/"},
+		{"should keep other domains", &http.Cookie{Name: "a", Domain: "example.org", Path: "/account"}, "", "example.org", "/account"},
+		{"should prefix path under removed prefix", &http.Cookie{Name: "a", Path: "/account"}, "/service-a", "", "/service-a/account"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			request := httptest.NewRequest(http.MethodGet, "http://public.example.com:8443/service-a/users", nil)
+			if test.Prefix != "" {
+				request = request.WithContext(WithForwardedPrefix(request.Context(), test.Prefix))
+			}
+
+			upstreamRequest := httptest.NewRequest(http.MethodGet, "http://10.0.0.5:8080/users", nil)
+			upstreamRequest.Host = "backend.internal"
+
+			// act
+			rewriteUpstreamCookie(test.Cookie, upstreamRequest, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedDomain, test.Cookie.Domain)
+			assert.Equal(t, test.ExpectedPath, test.Cookie.Path)
+		})
+	}
+}
@@ -24,7 +24,10 @@ func CreateLoadBalancer(logger *slog.Logger) *LoadBalancer {
 func (lb *LoadBalancer) MapApplication(ctx context.Context, name string, pathPrefix string, sb *core.ServiceBalancer) core.Application {
 	application := CreateApplication(name, sb, lb.logger)
 
-	lb.router.HandleFunc(pathPrefix, application.Handler)
+	lb.router.Handle(pathPrefix, core.ChainMiddlewares(http.HandlerFunc(application.Handler),
+		core.CreateRequestIdMiddleware(),
+		core.CreatePanicRecoveryMiddleware(lb.logger),
+	))
 
 	lb.applications = append(lb.applications, application)
 
@@ -0,0 +1,40 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationAuthentication(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+	authentication, err := core.CreateAuthentication("X-Consumer-Id",
+		core.CreateApiKeyAuthenticator("goprx", "X-Api-Key", "", map[string]string{"secret-key": "billing-service"}))
+	require.NoError(t, err)
+	reverseProxy.applications[0].SetAuthentication(authentication)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil)
+	request.Header.Set("X-Api-Key", "secret-key")
+	response := httptest.NewRecorder()
+	unauthorizedResponse := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+	reverseProxy.router.ServeHTTP(unauthorizedResponse, httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil))
+
+	// assert
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var upstreamRequest HttpTestResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &upstreamRequest))
+	assert.Equal(t, "billing-service", upstreamRequest.RequestHeaders.Get("X-Consumer-Id"))
+	assert.Empty(t, upstreamRequest.RequestHeaders.Get("X-Api-Key"))
+	assert.Equal(t, http.StatusUnauthorized, unauthorizedResponse.Code)
+	assert.Equal(t, `ApiKey realm="goprx"`, unauthorizedResponse.Header().Get("WWW-Authenticate"))
+}
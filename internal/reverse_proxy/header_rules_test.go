@@ -0,0 +1,47 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationHeaderRules(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathTemplateMatcher("/users/{id}"), handlerWithRequestAsResponseContent())
+
+	routeHeader, err := core.CreateSetHeaderRule("X-Route", "{route}:{param.id}")
+	require.NoError(t, err)
+	poweredBy, err := core.CreateSetHeaderRule("X-Powered-By", "goprx")
+	require.NoError(t, err)
+
+	reverseProxy.applications[0].
+		SetRequestHeaderRules(routeHeader, core.CreateRemoveHeaderRule("X-Internal"), core.CreateRenameHeaderRule("X-User", "X-Consumer")).
+		SetResponseHeaderRules(core.CreateRemoveHeaderRule("Content-Type"), poweredBy)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/users/42", nil)
+	request.Header.Set("X-Internal", "secret")
+	request.Header.Set("X-User", "alice")
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+
+	// assert
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var upstreamRequest HttpTestResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &upstreamRequest))
+	assert.Equal(t, reverseProxy.applications[0].Name+":42", upstreamRequest.RequestHeaders.Get("X-Route"))
+	assert.Empty(t, upstreamRequest.RequestHeaders.Get("X-Internal"))
+	assert.Empty(t, upstreamRequest.RequestHeaders.Get("X-User"))
+	assert.Equal(t, "alice", upstreamRequest.RequestHeaders.Get("X-Consumer"))
+
+	assert.Empty(t, response.Header().Get("Content-Type"))
+	assert.Equal(t, "goprx", response.Header().Get("X-Powered-By"))
+}
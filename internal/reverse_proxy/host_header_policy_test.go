@@ -0,0 +1,84 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApplicationHostHeaderPolicy(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Policy       *core.HostHeaderPolicy
+		ExpectedHost func(upstreamHost string) string
+	}{
+		{"should send upstream host by default", nil, func(upstreamHost string) string { return upstreamHost }},
+		{"should send upstream host", core.CreateUpstreamHostHeaderPolicy(), func(upstreamHost string) string { return upstreamHost }},
+		{"should preserve client host", core.CreatePreserveHostHeaderPolicy(), func(string) string { return "public.example.com" }},
+		{"should send fixed host", core.CreateFixedHostHeaderPolicy("backend.internal"), func(string) string { return "backend.internal" }},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			upstreamHost := reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+			if test.Policy != nil {
+				reverseProxy.applications[0].SetHostHeaderPolicy(test.Policy)
+			}
+
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://public.example.com/", nil))
+
+			// assert
+			require.Equal(t, http.StatusOK, response.Code)
+
+			var upstreamRequest HttpTestResponse
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &upstreamRequest))
+			assert.Equal(t, test.ExpectedHost(upstreamHost), upstreamRequest.Host)
+			assert.Equal(t, "public.example.com", upstreamRequest.RequestHeaders.Get("X-Forwarded-Host"))
+		})
+	}
+}
+
+func TestApplicationShouldRewriteUpstreamRedirects(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/service-a/"), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/account" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		upstreamHost := strings.Split(r.Host, ":")[0]
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Domain: upstreamHost, Path: "/"})
+		http.Redirect(w, r, "http://"+r.Host+"/login", http.StatusFound)
+	})
+
+	stripPrefix, err := core.CreateStripPrefixRewriteRule("/service-a")
+	require.NoError(t, err)
+	reverseProxy.applications[0].RewritePath(stripPrefix)
+
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://public.example.com/service-a/account", nil))
+
+	// assert
+	require.Equal(t, http.StatusFound, response.Code)
+	assert.Equal(t, "http://public.example.com/service-a/login", response.Header().Get("Location"))
+
+	cookies := response.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "public.example.com", cookies[0].Domain)
+	assert.Equal(t, "/service-a/", cookies[0].Path)
+}
@@ -0,0 +1,53 @@
+package reverse_proxy
+
+import (
+	"context"
+	"github.com/noelmugnier/goprx/internal/core"
+	"net"
+	"net/http"
+	"time"
+)
+
+type ListenerConfig struct {
+	Address               string
+	ReadHeaderTimeoutInMs time.Duration
+	ReadTimeoutInMs       time.Duration
+	WriteTimeoutInMs      time.Duration
+	IdleTimeoutInMs       time.Duration
+	// Limits are enforced on the requests of the listener, none are when nil,
+	// see core.CreateDefaultRequestLimitsConfig
+	Limits *core.RequestLimitsConfig
+}
+
+func CreateListenerConfig(address string) *ListenerConfig {
+	return &ListenerConfig{
+		Address:               address,
+		ReadHeaderTimeoutInMs: 10000,
+		ReadTimeoutInMs:       60000,
+		WriteTimeoutInMs:      60000,
+		IdleTimeoutInMs:       120000,
+	}
+}
+
+// CreateServer creates the server of a listener, its requests are rejected when they exceed the limits of the listener
+func (r *ReverseProxy) CreateServer(cfg *ListenerConfig) *http.Server {
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           r.router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeoutInMs * time.Millisecond,
+		ReadTimeout:       cfg.ReadTimeoutInMs * time.Millisecond,
+		WriteTimeout:      cfg.WriteTimeoutInMs * time.Millisecond,
+		IdleTimeout:       cfg.IdleTimeoutInMs * time.Millisecond,
+		BaseContext: func(net.Listener) context.Context {
+			return core.WithRequestLimits(context.Background(), cfg.Limits)
+		},
+	}
+
+	// the request line and the headers are checked by the limits middleware to answer with a 414 or a 431,
+	// the server only drops the requests far larger than them
+	if cfg.Limits != nil && cfg.Limits.MaxUriLength > 0 && cfg.Limits.MaxHeaderBytes > 0 {
+		server.MaxHeaderBytes = cfg.Limits.MaxUriLength + cfg.Limits.MaxHeaderBytes
+	}
+
+	return server
+}
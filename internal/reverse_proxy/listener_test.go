@@ -0,0 +1,89 @@
+package reverse_proxy
+
+import (
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenerLimits(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+
+	cfg := CreateListenerConfig("")
+	cfg.Limits = &core.RequestLimitsConfig{MaxBodyBytes: 16, MaxHeaderCount: 20, MaxHeaderBytes: 1024, MaxUriLength: 64}
+
+	listener := httptest.NewUnstartedServer(nil)
+	listener.Config = reverseProxy.CreateServer(cfg)
+	listener.Start()
+	defer listener.Close()
+
+	testCases := []struct {
+		Name           string
+		Url            string
+		Body           io.Reader
+		ExpectedStatus int
+	}{
+		{Name: "should forward request within limits", Url: listener.URL + "/users", Body: strings.NewReader("small body"), ExpectedStatus: http.StatusOK},
+		{Name: "should reject too large streamed body", Url: listener.URL + "/users", Body: io.MultiReader(strings.NewReader(strings.Repeat("a", 10)), strings.NewReader(strings.Repeat("b", 10))), ExpectedStatus: http.StatusRequestEntityTooLarge},
+		{Name: "should reject too long uri", Url: listener.URL + "/users/" + strings.Repeat("a", 64), ExpectedStatus: http.StatusRequestURITooLong},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, test.Url, test.Body)
+			require.NoError(t, err)
+
+			// act
+			response, err := http.DefaultClient.Do(request)
+
+			// assert
+			require.NoError(t, err)
+			_ = response.Body.Close()
+			assert.Equal(t, test.ExpectedStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestCreateServer(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	cfg := CreateListenerConfig(":8080")
+
+	// act
+	server := reverseProxy.CreateServer(cfg)
+
+	// assert
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, 10*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, time.Minute, server.ReadTimeout)
+	assert.Equal(t, time.Minute, server.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, server.IdleTimeout)
+	assert.Nil(t, cfg.Limits, "the limits must be opted in")
+	assert.Zero(t, server.MaxHeaderBytes)
+}
+
+func TestListenerLimitsShouldRejectWithRequestId(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/users/"+strings.Repeat("a", 64), nil)
+	request = request.WithContext(core.WithRequestLimits(request.Context(), &core.RequestLimitsConfig{MaxUriLength: 64}))
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+
+	// assert
+	assert.Equal(t, http.StatusRequestURITooLong, response.Code)
+	assert.NotEmpty(t, response.Header().Get(core.RequestIdHeader))
+	assert.Contains(t, response.Body.String(), "request_id: "+response.Header().Get(core.RequestIdHeader))
+}
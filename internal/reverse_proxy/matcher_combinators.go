@@ -0,0 +1,117 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type AllOfMatcher struct {
+	matchers []Matcher
+}
+
+type AnyOfMatcher struct {
+	matchers []Matcher
+}
+
+type NotMatcher struct {
+	matcher Matcher
+}
+
+func CreateAllOfMatcher(matchers ...Matcher) *AllOfMatcher {
+	return &AllOfMatcher{
+		matchers: matchers,
+	}
+}
+
+func CreateAnyOfMatcher(matchers ...Matcher) *AnyOfMatcher {
+	return &AnyOfMatcher{
+		matchers: matchers,
+	}
+}
+
+func CreateNotMatcher(matcher Matcher) *NotMatcher {
+	return &NotMatcher{
+		matcher: matcher,
+	}
+}
+
+func (m *AllOfMatcher) Match(r *http.Request) bool {
+	for _, matcher := range m.matchers {
+		if !matcher.Match(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *AnyOfMatcher) Match(r *http.Request) bool {
+	for _, matcher := range m.matchers {
+		if matcher.Match(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *NotMatcher) Match(r *http.Request) bool {
+	return !m.matcher.Match(r)
+}
+
+func (m *AllOfMatcher) Specificity() int {
+	specificity := 0
+	for _, matcher := range m.matchers {
+		specificity += matcherSpecificity(matcher)
+	}
+
+	return specificity
+}
+
+// Specificity of AnyOfMatcher is the one of its least specific branch since any of them can match the request
+func (m *AnyOfMatcher) Specificity() int {
+	if len(m.matchers) == 0 {
+		return 0
+	}
+
+	specificity := matcherSpecificity(m.matchers[0])
+	for _, matcher := range m.matchers[1:] {
+		specificity = min(specificity, matcherSpecificity(matcher))
+	}
+
+	return specificity
+}
+
+func (m *NotMatcher) Specificity() int {
+	return 1
+}
+
+func (m *AllOfMatcher) String() string {
+	return describeMatchers(m.matchers, " && ", "true")
+}
+
+func (m *AnyOfMatcher) String() string {
+	return describeMatchers(m.matchers, " || ", "false")
+}
+
+func (m *NotMatcher) String() string {
+	return fmt.Sprintf("!%s", describeMatcher(m.matcher))
+}
+
+func describeMatchers(matchers []Matcher, operator string, empty string) string {
+	if len(matchers) == 0 {
+		return empty
+	}
+
+	if len(matchers) == 1 {
+		return describeMatcher(matchers[0])
+	}
+
+	descriptions := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		descriptions[i] = describeMatcher(matcher)
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(descriptions, operator))
+}
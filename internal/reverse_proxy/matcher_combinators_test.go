@@ -0,0 +1,144 @@
+package reverse_proxy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatcherCombinators(t *testing.T) {
+	apiPostWithTenant := CreateAllOfMatcher(
+		CreateTestPathPrefixMatcher("/api"),
+		CreateTestMethodsMatcher(http.MethodPost),
+		CreateTestHeadersMatcher(map[string]string{"X-Tenant": ".+"}),
+	)
+
+	testCases := []struct {
+		Name          string
+		Matcher       Matcher
+		Method, Url   string
+		Headers       map[string]string
+		ExpectedMatch bool
+	}{
+		{
+			Name:          "all of should match when every matcher succeed",
+			Matcher:       apiPostWithTenant,
+			Method:        http.MethodPost,
+			Url:           "http://localhost/api/orders",
+			Headers:       map[string]string{"X-Tenant": "acme"},
+			ExpectedMatch: true,
+		},
+		{
+			Name:          "all of should not match when one matcher fail",
+			Matcher:       apiPostWithTenant,
+			Method:        http.MethodPost,
+			Url:           "http://localhost/api/orders",
+			ExpectedMatch: false,
+		},
+		{
+			Name:          "all of should match when empty",
+			Matcher:       CreateAllOfMatcher(),
+			Method:        http.MethodGet,
+			Url:           "http://localhost/",
+			ExpectedMatch: true,
+		},
+		{
+			Name:          "any of should match when one matcher succeed",
+			Matcher:       CreateAnyOfMatcher(CreateTestPathPrefixMatcher("/admin"), CreateTestMethodsMatcher(http.MethodDelete)),
+			Method:        http.MethodDelete,
+			Url:           "http://localhost/api/orders",
+			ExpectedMatch: true,
+		},
+		{
+			Name:          "any of should not match when empty",
+			Matcher:       CreateAnyOfMatcher(),
+			Method:        http.MethodGet,
+			Url:           "http://localhost/",
+			ExpectedMatch: false,
+		},
+		{
+			Name:          "not should invert matcher result",
+			Matcher:       CreateNotMatcher(CreateTestMethodsMatcher(http.MethodGet)),
+			Method:        http.MethodGet,
+			Url:           "http://localhost/api",
+			ExpectedMatch: false,
+		},
+		{
+			Name: "nested tree should match path and (method or debug header)",
+			Matcher: CreateAllOfMatcher(
+				CreateTestPathPrefixMatcher("/v2"),
+				CreateAnyOfMatcher(
+					CreateTestMethodsMatcher(http.MethodGet),
+					CreateTestHeadersMatcher(map[string]string{"X-Debug": "^1$"}),
+				),
+			),
+			Method:        http.MethodPost,
+			Url:           "http://localhost/v2/users",
+			Headers:       map[string]string{"X-Debug": "1"},
+			ExpectedMatch: true,
+		},
+		{
+			Name: "nested tree should not match excluded branch",
+			Matcher: CreateAllOfMatcher(
+				CreateTestPathPrefixMatcher("/v2"),
+				CreateNotMatcher(CreateAnyOfMatcher(
+					CreateTestPathPrefixMatcher("/v2/internal"),
+					CreateTestQueryParamsMatcher(map[string]string{"debug": "^true$"}),
+				)),
+			),
+			Method:        http.MethodGet,
+			Url:           "http://localhost/v2/users?debug=true",
+			ExpectedMatch: false,
+		},
+		{
+			Name: "nested tree should match when excluded branch does not",
+			Matcher: CreateAllOfMatcher(
+				CreateTestPathPrefixMatcher("/v2"),
+				CreateNotMatcher(CreateAnyOfMatcher(
+					CreateTestPathPrefixMatcher("/v2/internal"),
+					CreateTestQueryParamsMatcher(map[string]string{"debug": "^true$"}),
+				)),
+			),
+			Method:        http.MethodGet,
+			Url:           "http://localhost/v2/users?debug=false",
+			ExpectedMatch: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			request := httptest.NewRequest(test.Method, test.Url, nil)
+			for headerName, headerValue := range test.Headers {
+				request.Header.Add(headerName, headerValue)
+			}
+
+			// act
+			match := test.Matcher.Match(request)
+
+			// assert
+			assert.Equal(t, test.ExpectedMatch, match)
+		})
+	}
+
+	t.Run("should forward to application matching a composed tree", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		reverseProxy := createTestReverseProxy()
+		reverseProxy.registerTestApplicationAndWait(apiPostWithTenant, handlerWithRequestAsResponseContent())
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost/api/orders", nil)
+		request.Header.Set("X-Tenant", "acme")
+		response := httptest.NewRecorder()
+
+		// act
+		reverseProxy.router.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
@@ -0,0 +1,35 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationPathRewrite(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/service-a/"), handlerWithRequestAsResponseContent())
+
+	stripPrefix, err := core.CreateStripPrefixRewriteRule("/service-a")
+	require.NoError(t, err)
+	reverseProxy.applications[0].RewritePath(stripPrefix)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/service-a/files/a%2Fb?version=2", nil)
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+
+	// assert
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var upstreamRequest HttpTestResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &upstreamRequest))
+	assert.Equal(t, "/files/a%2Fb?version=2", upstreamRequest.RequestUrl)
+	assert.Equal(t, "/service-a", upstreamRequest.RequestHeaders.Get(core.ForwardedPrefixHeader))
+}
@@ -2,51 +2,221 @@ package reverse_proxy
 
 import (
 	"context"
+	"fmt"
 	"github.com/noelmugnier/goprx/internal/core"
 	"log/slog"
 	"net/http"
+	"slices"
 )
 
 type ProxifiedApplication struct {
-	logger   *slog.Logger
-	sb       *core.ServiceBalancer
-	Name     string
-	matchers []Matcher
+	logger      *slog.Logger
+	sb          *core.ServiceBalancer
+	split       *core.TrafficSplit
+	responder   http.Handler
+	constraints *routeConstraints
+	Name        string
+	Priority    int
+	matcher     Matcher
+	middlewares []core.Middleware
+	slots       applicationMiddlewareSlots
+
+	responseHeaderRules []*core.HeaderRule
+}
+
+// applicationMiddlewareSlots holds the middlewares of the Set methods, chained in the order of the fields
+type applicationMiddlewareSlots struct {
+	ipAccessPolicy     core.Middleware
+	authentication     core.Middleware
+	rateLimiter        core.Middleware
+	concurrencyLimiter core.Middleware
+	pathRewrite        core.Middleware
+	requestHeaderRules core.Middleware
+	hostHeaderPolicy   core.Middleware
+	trafficMirror      core.Middleware
+}
+
+// chain returns the slots which are set in their order
+func (s *applicationMiddlewareSlots) chain() []core.Middleware {
+	slots := []core.Middleware{
+		s.ipAccessPolicy,
+		s.authentication,
+		s.rateLimiter,
+		s.concurrencyLimiter,
+		s.pathRewrite,
+		s.requestHeaderRules,
+		s.hostHeaderPolicy,
+		s.trafficMirror,
+	}
+
+	return slices.DeleteFunc(slots, func(middleware core.Middleware) bool { return middleware == nil })
 }
 
 type Matcher interface {
 	Match(r *http.Request) bool
 }
 
-func CreateApplication(name string, matchers []Matcher, sb *core.ServiceBalancer, logger *slog.Logger) *ProxifiedApplication {
+func CreateApplication(name string, matcher Matcher, sb *core.ServiceBalancer, logger *slog.Logger) *ProxifiedApplication {
+	return &ProxifiedApplication{
+		matcher:     matcher,
+		constraints: deriveRouteConstraints(matcher),
+		logger:      logger.With(slog.String("application_name", name)),
+		Name:        name,
+		sb:          sb,
+
+		responseHeaderRules: core.DefaultResponseHeaderRules(),
+	}
+}
+
+// CreateSplitApplication creates an application splitting its requests across the balancers of the split,
+// services are registered on those balancers directly
+func CreateSplitApplication(name string, matcher Matcher, split *core.TrafficSplit, logger *slog.Logger) *ProxifiedApplication {
+	return &ProxifiedApplication{
+		matcher:     matcher,
+		constraints: deriveRouteConstraints(matcher),
+		logger:      logger.With(slog.String("application_name", name)),
+		Name:        name,
+		split:       split,
+
+		responseHeaderRules: core.DefaultResponseHeaderRules(),
+	}
+}
+
+// CreateHandlerApplication creates an application answering the requests itself without any upstream,
+// such as a core.RedirectHandler, a core.DirectResponseHandler or a core.StaticFilesHandler
+func CreateHandlerApplication(name string, matcher Matcher, responder http.Handler, logger *slog.Logger) *ProxifiedApplication {
 	return &ProxifiedApplication{
-		matchers: matchers,
-		logger:   logger.With(slog.String("application_name", name)),
-		Name:     name,
-		sb:       sb,
+		matcher:     matcher,
+		constraints: deriveRouteConstraints(matcher),
+		logger:      logger.With(slog.String("application_name", name)),
+		Name:        name,
+		responder:   responder,
 	}
 }
 
 func (a *ProxifiedApplication) RegisterService(ctx context.Context, cfg *core.ServiceConfig) *core.Service {
+	if a.sb == nil {
+		a.logger.Log(ctx, slog.LevelError, "cannot register service on an application without its own balancer")
+		return nil
+	}
+
 	return a.sb.RegisterService(ctx, cfg)
 }
 
 func (a *ProxifiedApplication) UnregisterService(ctx context.Context, host string) error {
+	if a.sb == nil {
+		return fmt.Errorf("application %q has no balancer of its own", a.Name)
+	}
+
 	return a.sb.UnregisterService(ctx, host)
 }
 
+// TrafficSplit returns the split of the application, nil when it forwards to a single balancer
+func (a *ProxifiedApplication) TrafficSplit() *core.TrafficSplit {
+	return a.split
+}
+
+func (a *ProxifiedApplication) balancers() []*core.ServiceBalancer {
+	if a.split != nil {
+		return a.split.Balancers()
+	}
+
+	if a.sb == nil {
+		return nil
+	}
+
+	return []*core.ServiceBalancer{a.sb}
+}
+
+func (a *ProxifiedApplication) upstream() core.UpstreamHandler {
+	if a.split != nil {
+		return a.split
+	}
+
+	return a.sb
+}
+
+// Use wraps the application handler with the middlewares, the first one being the outermost. They run before
+// the middlewares of the Set methods, each replacing the previous one it set, which run whatever the order they are
+// set in: access policy, authentication, rate limit, concurrency limit, path rewrite, request header rules,
+// host header policy then traffic mirror
+func (a *ProxifiedApplication) Use(middlewares ...core.Middleware) *ProxifiedApplication {
+	a.middlewares = append(a.middlewares, middlewares...)
+	return a
+}
+
+// SetIpAccessPolicy rejects with a 403 the requests whose client address is not allowed by the policy
+func (a *ProxifiedApplication) SetIpAccessPolicy(policy *core.IpAccessPolicy) *ProxifiedApplication {
+	a.slots.ipAccessPolicy = core.CreateIpAccessPolicyMiddleware(policy)
+	return a
+}
+
+// SetAuthentication rejects with a 401 the requests not authenticated by any of the authenticators of the authentication,
+// the identity of the consumer is forwarded upstream in the consumer header instead of the credential
+func (a *ProxifiedApplication) SetAuthentication(authentication *core.Authentication) *ProxifiedApplication {
+	a.slots.authentication = authentication.Middleware()
+	return a
+}
+
+// RewritePath rewrites the path of the requests with the rules, applied in order, before they are forwarded
+func (a *ProxifiedApplication) RewritePath(rules ...core.PathRewriteRule) *ProxifiedApplication {
+	a.slots.pathRewrite = core.CreatePathRewriteMiddleware(rules...)
+	return a
+}
+
+// SetRequestHeaderRules applies the rules, in order, to the headers of the requests forwarded upstream
+func (a *ProxifiedApplication) SetRequestHeaderRules(rules ...*core.HeaderRule) *ProxifiedApplication {
+	a.slots.requestHeaderRules = core.CreateRequestHeaderRulesMiddleware(rules...)
+	return a
+}
+
+// SetResponseHeaderRules replaces the rules applied to the upstream responses,
+// include core.DefaultResponseHeaderRules to keep removing the headers disclosing the upstream technologies
+func (a *ProxifiedApplication) SetResponseHeaderRules(rules ...*core.HeaderRule) *ProxifiedApplication {
+	a.responseHeaderRules = rules
+	return a
+}
+
+// SetHostHeaderPolicy decides the Host header of the requests forwarded upstream
+func (a *ProxifiedApplication) SetHostHeaderPolicy(policy *core.HostHeaderPolicy) *ProxifiedApplication {
+	a.slots.hostHeaderPolicy = core.CreateHostHeaderPolicyMiddleware(policy)
+	return a
+}
+
+// SetTrafficMirror mirrors a percentage of the requests of the application to the shadow of the mirror
+func (a *ProxifiedApplication) SetTrafficMirror(mirror *core.TrafficMirror) *ProxifiedApplication {
+	a.slots.trafficMirror = mirror.Middleware()
+	return a
+}
+
+// SetRateLimiter rejects with a 429 the requests exceeding the limit of the rate limiter
+func (a *ProxifiedApplication) SetRateLimiter(limiter *core.RateLimiter) *ProxifiedApplication {
+	a.slots.rateLimiter = limiter.Middleware()
+	return a
+}
+
+// SetConcurrencyLimiter bounds the requests handled at once by the application,
+// use core.ServiceBalancer.SetConcurrencyLimiter to bound the requests forwarded to a balancer instead
+func (a *ProxifiedApplication) SetConcurrencyLimiter(limiter *core.ConcurrencyLimiter) *ProxifiedApplication {
+	a.slots.concurrencyLimiter = limiter.Middleware()
+	return a
+}
+
 func (a *ProxifiedApplication) Handler(w http.ResponseWriter, r *http.Request) {
-	handler := core.CreateApplicationHandler(a.sb, a.logger)
-	handler(w, r)
+	var handler http.Handler = a.responder
+	if handler == nil {
+		handler = http.HandlerFunc(core.CreateApplicationHandlerWithHeaderRules(a.upstream(), a.responseHeaderRules, a.logger))
+	}
+
+	middlewares := append(slices.Clone(a.middlewares), a.slots.chain()...)
+	core.ChainMiddlewares(handler, middlewares...).ServeHTTP(w, r.WithContext(core.WithRouteName(r.Context(), a.Name)))
 }
 
 func (a *ProxifiedApplication) Match(r *http.Request) bool {
-	for _, matcher := range a.matchers {
-		if matcher.Match(r) {
-			return true
-		}
+	if a.matcher == nil {
+		return false
 	}
 
-	return false
+	return a.matcher.Match(r)
 }
 
@@ -0,0 +1,75 @@
+package reverse_proxy
+
+import (
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationMiddlewaresOrder(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+
+	limiter, err := core.CreateRateLimiter(core.RateLimitByRoute(), core.CreateRateLimitConfig(1, 60000), nil, slog.Default())
+	require.NoError(t, err)
+
+	policy, err := core.CreateIpAccessPolicy([]string{"192.168.0.0/16"}, nil)
+	require.NoError(t, err)
+
+	authentication, err := core.CreateAuthentication("X-Consumer-Id", core.CreateApiKeyAuthenticator("goprx", "X-Api-Key", "", map[string]string{"secret-key": "billing-service"}))
+	require.NoError(t, err)
+
+	reverseProxy.applications[0].
+		SetRateLimiter(limiter).
+		SetAuthentication(authentication).
+		SetIpAccessPolicy(policy)
+
+	forbiddenRequest := httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil)
+	forbiddenRequest.RemoteAddr = "203.0.113.7:3456"
+	forbiddenResponse := httptest.NewRecorder()
+	unauthorizedRequest := httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil)
+	unauthorizedRequest.RemoteAddr = "192.168.1.1:3456"
+	unauthorizedResponse := httptest.NewRecorder()
+	authenticatedRequest := httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil)
+	authenticatedRequest.RemoteAddr = "192.168.1.1:3456"
+	authenticatedRequest.Header.Set("X-Api-Key", "secret-key")
+	authenticatedResponse := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(forbiddenResponse, forbiddenRequest)
+	reverseProxy.router.ServeHTTP(unauthorizedResponse, unauthorizedRequest)
+	reverseProxy.router.ServeHTTP(authenticatedResponse, authenticatedRequest)
+
+	// assert
+	assert.Equal(t, http.StatusForbidden, forbiddenResponse.Code)
+	assert.Equal(t, http.StatusUnauthorized, unauthorizedResponse.Code)
+	assert.Equal(t, http.StatusOK, authenticatedResponse.Code, "rejected requests must not consume the rate limit")
+}
+
+func TestApplicationSetShouldReplaceMiddleware(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+
+	deniedPolicy, err := core.CreateIpAccessPolicy([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	allowedPolicy, err := core.CreateIpAccessPolicy([]string{"192.168.0.0/16"}, nil)
+	require.NoError(t, err)
+
+	reverseProxy.applications[0].SetIpAccessPolicy(deniedPolicy).SetIpAccessPolicy(allowedPolicy)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/invoices", nil)
+	request.RemoteAddr = "192.168.1.1:3456"
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+
+	// assert
+	assert.Equal(t, http.StatusOK, response.Code)
+}
@@ -0,0 +1,35 @@
+package reverse_proxy
+
+import (
+	"context"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationRateLimiter(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	limiter, err := core.CreateRateLimiter(core.RateLimitByRoute(), &core.RateLimitConfig{Requests: 1, PeriodInMs: 60000, Burst: 1, MaxKeys: 10}, nil, slog.Default())
+	require.NoError(t, err)
+
+	reverseProxy.MapDirectResponse(context.Background(), "limited", CreateTestPathPrefixMatcher("/limited"), 0, http.StatusOK, "ok", nil).SetRateLimiter(limiter)
+	reverseProxy.MapDirectResponse(context.Background(), "unlimited", CreateTestPathPrefixMatcher("/unlimited"), 0, http.StatusOK, "ok", nil)
+
+	statuses := make([]int, 0, 4)
+	for _, url := range []string{"http://localhost/limited", "http://localhost/limited", "http://localhost/unlimited", "http://localhost/unlimited"} {
+		response := httptest.NewRecorder()
+
+		// act
+		reverseProxy.router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, url, nil))
+
+		statuses = append(statuses, response.Code)
+	}
+
+	// assert
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK}, statuses)
+}
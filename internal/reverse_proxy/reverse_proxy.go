@@ -2,31 +2,39 @@ package reverse_proxy
 
 import (
 	"context"
-	"fmt"
 	"github.com/noelmugnier/goprx/internal/core"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"sync"
 )
 
 type ReverseProxy struct {
-	applications []*ProxifiedApplication
-	router       *http.ServeMux
-	logger       *slog.Logger
+	mutex            sync.RWMutex
+	applications     []*ProxifiedApplication
+	index            *routeIndex
+	router           *http.ServeMux
+	logger           *slog.Logger
+	zone             string
+	errorRenderer    core.ErrorRenderer
+	clientIpResolver *core.ClientIpResolver
 }
 
 func CreateReverseProxy(logger *slog.Logger) *ReverseProxy {
 	reverseProxy := &ReverseProxy{
-		applications: make([]*ProxifiedApplication, 0),
-		logger:       logger,
-		router:       http.NewServeMux(),
+		applications:  make([]*ProxifiedApplication, 0),
+		logger:        logger,
+		router:        http.NewServeMux(),
+		errorRenderer: core.CreateDefaultErrorRenderer(),
 	}
 
-	reverseProxy.router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	reverseProxy.clientIpResolver, _ = core.CreateClientIpResolver(nil)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		application, err := reverseProxy.getMatchingApplication(r)
 
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			_, err := w.Write([]byte("no matching application found"))
+			err := core.WriteError(w, r, err)
 
 			if err != nil {
 				logger.Log(r.Context(), slog.LevelError, "cannot write error to client", slog.Any("error", err))
@@ -35,30 +43,168 @@ func CreateReverseProxy(logger *slog.Logger) *ReverseProxy {
 			return
 		}
 
+		if params := extractPathParams(application.matcher, r); len(params) > 0 {
+			r = r.WithContext(core.WithPathParams(r.Context(), params))
+		}
+
 		application.Handler(w, r)
 	})
 
+	// the requests exceeding the limits are rejected as soon as their error can be identified and rendered
+	reverseProxy.router.Handle("/", core.ChainMiddlewares(handler,
+		core.CreateRequestIdMiddleware(),
+		reverseProxy.errorRendererMiddleware,
+		core.CreateRequestLimitsMiddleware(),
+		core.CreatePanicRecoveryMiddleware(logger),
+		reverseProxy.clientIpMiddleware,
+	))
+
 	return reverseProxy
 }
 
-func (r *ReverseProxy) MapApplication(ctx context.Context, name string, matchers []Matcher, lb *core.ServiceBalancer) *ProxifiedApplication {
-	application := CreateApplication(name, matchers, lb, r.logger)
+func (r *ReverseProxy) SetErrorRenderer(renderer core.ErrorRenderer) {
+	r.errorRenderer = renderer
+}
+
+func (r *ReverseProxy) errorRendererMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req.WithContext(core.WithErrorRenderer(req.Context(), r.errorRenderer)))
+	})
+}
+
+// SetTrustedProxies lists the proxies whose X-Forwarded-For header is used to resolve the client address
+func (r *ReverseProxy) SetTrustedProxies(ranges []string) error {
+	resolver, err := core.CreateClientIpResolver(ranges)
+	if err != nil {
+		return err
+	}
+
+	r.clientIpResolver = resolver
+	return nil
+}
+
+func (r *ReverseProxy) clientIpMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req.WithContext(core.WithClientIp(req.Context(), r.clientIpResolver.Resolve(req))))
+	})
+}
+
+// SetLocality sets the zone of the proxy, the balancers of the applications without LocalZone prefer its services,
+// including the ones of the applications already mapped
+func (r *ReverseProxy) SetLocality(zone string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.zone = zone
+	for _, application := range r.applications {
+		for _, lb := range application.balancers() {
+			lb.SetDefaultLocalZone(zone)
+		}
+	}
+}
+
+func (r *ReverseProxy) MapApplication(ctx context.Context, name string, matcher Matcher, lb *core.ServiceBalancer) *ProxifiedApplication {
+	return r.MapApplicationWithPriority(ctx, name, matcher, 0, lb)
+}
+
+// MapApplicationWithRule maps an application matching the requests satisfying the rule, see ParseRule for its syntax
+func (r *ReverseProxy) MapApplicationWithRule(ctx context.Context, name string, rule string, priority int, lb *core.ServiceBalancer) (*ProxifiedApplication, error) {
+	matcher, err := CompileRule(rule)
+	if err != nil {
+		return nil, err
+	}
 
-	r.applications = append(r.applications, application)
+	return r.MapApplicationWithPriority(ctx, name, matcher, priority, lb), nil
+}
+
+// MapApplicationWithPriority maps an application tested before the ones with a lower priority,
+// a priority of 0 lets the proxy compute it from the matcher specificity
+func (r *ReverseProxy) MapApplicationWithPriority(ctx context.Context, name string, matcher Matcher, priority int, lb *core.ServiceBalancer) *ProxifiedApplication {
+	application := CreateApplication(name, matcher, lb, r.logger)
+	application.Priority = priority
+
+	return r.mapApplication(ctx, application)
+}
+
+// MapSplitApplication maps an application splitting its requests across the balancers of the split, see MapApplicationWithPriority
+func (r *ReverseProxy) MapSplitApplication(ctx context.Context, name string, matcher Matcher, priority int, split *core.TrafficSplit) *ProxifiedApplication {
+	application := CreateSplitApplication(name, matcher, split, r.logger)
+	application.Priority = priority
+
+	return r.mapApplication(ctx, application)
+}
+
+// MapRedirect maps an application redirecting the requests with the status to the target, see core.CreateRedirectHandler
+func (r *ReverseProxy) MapRedirect(ctx context.Context, name string, matcher Matcher, priority int, status int, targetTemplate string) (*ProxifiedApplication, error) {
+	redirect, err := core.CreateRedirectHandler(status, targetTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	application := CreateHandlerApplication(name, matcher, redirect, r.logger)
+	application.Priority = priority
+
+	return r.mapApplication(ctx, application), nil
+}
+
+// MapDirectResponse maps an application answering the requests with a fixed status, body and headers
+func (r *ReverseProxy) MapDirectResponse(ctx context.Context, name string, matcher Matcher, priority int, status int, body string, headers http.Header) *ProxifiedApplication {
+	application := CreateHandlerApplication(name, matcher, core.CreateDirectResponseHandler(status, body, headers), r.logger)
+	application.Priority = priority
+
+	return r.mapApplication(ctx, application)
+}
+
+// MapStaticFiles maps an application serving the files of fsys, use os.DirFS to serve a local directory,
+// the files are resolved from the full path of the request so the prefix of a route such as /assets/ must be
+// stripped with RewritePath to serve <root>/app.js instead of <root>/assets/app.js, see core.CreateStaticFilesHandler
+func (r *ReverseProxy) MapStaticFiles(ctx context.Context, name string, matcher Matcher, priority int, fsys fs.FS, config *core.StaticFilesConfig) *ProxifiedApplication {
+	application := CreateHandlerApplication(name, matcher, core.CreateStaticFilesHandler(fsys, config), r.logger)
+	application.Priority = priority
+
+	return r.mapApplication(ctx, application)
+}
+
+func (r *ReverseProxy) mapApplication(ctx context.Context, application *ProxifiedApplication) *ProxifiedApplication {
+	r.mutex.Lock()
+	for _, lb := range application.balancers() {
+		lb.SetDefaultLocalZone(r.zone)
+	}
+
+	r.insertApplication(application)
+	r.warnAboutAmbiguousRoutes(ctx, application)
+	r.mutex.Unlock()
 
 	r.logger.Log(ctx, slog.LevelInfo, "application mapped")
 	return application
 }
 
 func (r *ReverseProxy) getMatchingApplication(req *http.Request) (*ProxifiedApplication, error) {
-	for _, app := range r.applications {
-		if app.Match(req) {
-			return app, nil
-		}
+	if application := r.getRouteIndex().lookup(req); application != nil {
+		return application, nil
 	}
 
-	err := fmt.Errorf("no matching application found")
-	r.logger.Log(req.Context(), slog.LevelInfo, err.Error())
+	r.logger.Log(req.Context(), slog.LevelInfo, core.NoMatchingApplicationErr.Error())
+
+	return nil, core.NoMatchingApplicationErr
+}
+
+// getRouteIndex returns the index of the mapped applications, building it after applications were mapped
+func (r *ReverseProxy) getRouteIndex() *routeIndex {
+	r.mutex.RLock()
+	index := r.index
+	r.mutex.RUnlock()
 
-	return nil, err
-}
\ No newline at end of file
+	if index != nil {
+		return index
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.index == nil {
+		r.index = createRouteIndex(r.applications)
+	}
+
+	return r.index
+}
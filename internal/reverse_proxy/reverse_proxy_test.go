@@ -21,7 +21,7 @@ import (
 func TestReverseProxy(t *testing.T) {
 	pathMatcher := CreateTestPathPrefixMatcher("/simple-query")
 	methodsMatcher := CreateTestMethodsMatcher(http.MethodPost)
-	matchers := []Matcher{pathMatcher, methodsMatcher}
+	matcher := CreateAnyOfMatcher(pathMatcher, methodsMatcher)
 
 	testCases := []struct {
 		Name, Method, Url  string
@@ -38,7 +38,7 @@ func TestReverseProxy(t *testing.T) {
 
 			// arrange
 			reverseProxy := createTestReverseProxy()
-			reverseProxy.registerTestApplicationAndWait(matchers, handlerWithStatusCode(http.StatusOK))
+			reverseProxy.registerTestApplicationAndWait(matcher, handlerWithStatusCode(http.StatusOK))
 
 			request := httptest.NewRequest(test.Method, test.Url, nil)
 			response := httptest.NewRecorder()
@@ -50,6 +50,80 @@ func TestReverseProxy(t *testing.T) {
 			assert.Equal(t, test.ExpectedStatusCode, response.Code)
 		})
 	}
+
+	t.Run("should render not found error with requested format", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		reverseProxy := createTestReverseProxy()
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/unknown", nil)
+		request.Header.Set("Accept", "application/json")
+		response := httptest.NewRecorder()
+
+		// act
+		reverseProxy.router.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+		assert.Contains(t, response.Body.String(), `"code":"no_matching_application"`)
+		assert.Contains(t, response.Body.String(), response.Header().Get(core.RequestIdHeader))
+	})
+
+	t.Run("should recover from a panicking matcher", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		reverseProxy := createTestReverseProxy()
+		reverseProxy.registerTestApplicationAndNoWait(&panickingMatcher{}, handlerWithStatusCode(http.StatusOK))
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/simple-query", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		reverseProxy.router.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Contains(t, response.Body.String(), "code: internal_error")
+	})
+
+	t.Run("should apply proxy locality to mapped service balancers without zone", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		logger := slog.Default()
+		reverseProxy := createTestReverseProxy()
+		reverseProxy.SetLocality("eu-west-1a")
+
+		lb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 1, 1), logger)
+		zonedCfg := core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 1, 1)
+		zonedCfg.LocalZone = "eu-west-1b"
+		zonedLb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), zonedCfg, logger)
+
+		// act
+		reverseProxy.MapApplication(context.Background(), "app", matcher, lb)
+		reverseProxy.MapApplication(context.Background(), "zoned-app", matcher, zonedLb)
+
+		// assert
+		assert.Equal(t, "eu-west-1a", lb.LocalZone())
+		assert.Equal(t, "eu-west-1b", zonedLb.LocalZone())
+	})
+
+	t.Run("should apply proxy locality to service balancers mapped before it is set", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		logger := slog.Default()
+		reverseProxy := createTestReverseProxy()
+		lb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 1, 1), logger)
+		reverseProxy.MapApplication(context.Background(), "app", matcher, lb)
+
+		// act
+		reverseProxy.SetLocality("eu-west-1a")
+
+		// assert
+		assert.Equal(t, "eu-west-1a", lb.LocalZone())
+	})
 }
 
 func createTestReverseProxy() *ReverseProxy {
@@ -64,22 +138,22 @@ func createTestReverseProxy() *ReverseProxy {
 }
 
 func (r *ReverseProxy) registerTestApplicationAndWait(
-	matchers []Matcher,
+	matcher Matcher,
 	handler func(w http.ResponseWriter, r *http.Request)) string {
 
-	return registerTestApp(r, matchers, handler, true)
+	return registerTestApp(r, matcher, handler, true)
 }
 
 func (r *ReverseProxy) registerTestApplicationAndNoWait(
-	matchers []Matcher,
+	matcher Matcher,
 	handler func(w http.ResponseWriter, r *http.Request)) string {
 
-	return registerTestApp(r, matchers, handler, false)
+	return registerTestApp(r, matcher, handler, false)
 }
 
 func registerTestApp(
 	reverseProxy *ReverseProxy,
-	matchers []Matcher,
+	matcher Matcher,
 	handler func(w http.ResponseWriter, r *http.Request),
 	waitForAvailableService bool) string {
 	sbCfg := core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 1, 1)
@@ -92,7 +166,7 @@ func registerTestApp(
 	serviceCfg := createTestService(handler)
 	ctx := context.Background()
 
-	app := reverseProxy.MapApplication(ctx, uuid.NewString(), matchers, lb)
+	app := reverseProxy.MapApplication(ctx, uuid.NewString(), matcher, lb)
 	app.RegisterService(ctx, serviceCfg)
 
 	if waitForAvailableService {
@@ -144,6 +218,12 @@ func handlerWithStatusCode(returnedStatusCode int) func(w http.ResponseWriter, r
 	}
 }
 
+type panickingMatcher struct{}
+
+func (m *panickingMatcher) Match(_ *http.Request) bool {
+	panic("matcher exploded")
+}
+
 type HttpTestResponse struct {
 	RequestBody    []byte
 	Url            string
@@ -0,0 +1,47 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"github.com/noelmugnier/goprx/internal/core"
+	"net/http"
+	"net/netip"
+)
+
+// RouteClientIpMatcher matches the client address resolved from the trusted proxies against CIDR ranges
+type RouteClientIpMatcher struct {
+	prefixes []netip.Prefix
+	ranges   []string
+}
+
+func CreateRouteClientIpMatcher(ranges []string) (*RouteClientIpMatcher, error) {
+	prefixes, err := core.ParsePrefixes(ranges)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client ip range: %w", err)
+	}
+
+	matcher := &RouteClientIpMatcher{
+		prefixes: prefixes,
+		ranges:   ranges,
+	}
+
+	return matcher, nil
+}
+
+func (m *RouteClientIpMatcher) Match(r *http.Request) bool {
+	addr := core.ClientIp(r)
+	for _, prefix := range m.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *RouteClientIpMatcher) Specificity() int {
+	return 1
+}
+
+func (m *RouteClientIpMatcher) String() string {
+	return fmt.Sprintf("ClientIP(%s)", quoteValues(m.ranges))
+}
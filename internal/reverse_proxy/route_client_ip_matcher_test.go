@@ -0,0 +1,107 @@
+package reverse_proxy
+
+import (
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteClientIpMatcher(t *testing.T) {
+	testCases := []struct {
+		Name, RemoteAddr, ForwardedFor string
+		Matcher                        Matcher
+		ExpectedStatus                 int
+	}{
+		{
+			Name:           "should forward to application when client ip is in range",
+			Matcher:        CreateTestClientIpMatcher("192.168.0.0/16"),
+			RemoteAddr:     "192.168.10.4:3456",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when client ip is not in range",
+			Matcher:        CreateTestClientIpMatcher("192.168.0.0/16"),
+			RemoteAddr:     "203.0.113.7:3456",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when ipv6 client ip is in range",
+			Matcher:        CreateTestClientIpMatcher("192.168.0.0/16", "2001:db8::/32"),
+			RemoteAddr:     "[2001:db8::7]:3456",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when client ip forwarded by trusted proxy is in range",
+			Matcher:        CreateTestClientIpMatcher("192.168.0.0/16"),
+			RemoteAddr:     "10.0.0.1:3456",
+			ForwardedFor:   "192.168.10.4",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not trust client ip forwarded by untrusted proxy",
+			Matcher:        CreateTestClientIpMatcher("192.168.0.0/16"),
+			RemoteAddr:     "203.0.113.7:3456",
+			ForwardedFor:   "192.168.10.4",
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			require.NoError(t, reverseProxy.SetTrustedProxies([]string{"10.0.0.0/8"}))
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/admin", nil)
+			request.RemoteAddr = test.RemoteAddr
+			if test.ForwardedFor != "" {
+				request.Header.Set(core.ForwardedForHeader, test.ForwardedFor)
+			}
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+}
+
+func TestApplicationIpAccessPolicy(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/admin"), handlerWithRequestAsResponseContent())
+
+	policy, err := core.CreateIpAccessPolicy([]string{"192.168.0.0/16"}, nil)
+	require.NoError(t, err)
+	reverseProxy.applications[0].SetIpAccessPolicy(policy)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost/admin", nil)
+	request.RemoteAddr = "203.0.113.7:3456"
+	request.Header.Set("Accept", "application/problem+json")
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, request)
+
+	// assert
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), `"code":"forbidden"`)
+}
+
+func TestRouteClientIpMatcherCreation(t *testing.T) {
+	_, err := CreateRouteClientIpMatcher([]string{"192.168.0.0/40"})
+	assert.Error(t, err)
+}
+
+func CreateTestClientIpMatcher(ranges ...string) Matcher {
+	matcher, _ := CreateRouteClientIpMatcher(ranges)
+	return matcher
+}
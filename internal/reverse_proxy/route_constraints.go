@@ -0,0 +1,96 @@
+package reverse_proxy
+
+import (
+	"slices"
+	"strings"
+)
+
+// routeConstraints are the conditions a request must meet for a matcher to succeed,
+// nil hosts or methods and an empty path prefix mean the matcher does not constrain them
+type routeConstraints struct {
+	pathPrefix string
+	hosts      []string
+	methods    []string
+}
+
+func deriveRouteConstraints(matcher Matcher) *routeConstraints {
+	constraints := &routeConstraints{}
+
+	switch typedMatcher := matcher.(type) {
+	case *RoutePathPrefixMatcher:
+		constraints.pathPrefix = typedMatcher.prefix
+	case *RoutePathTemplateMatcher:
+		constraints.pathPrefix = typedMatcher.literalPrefix
+	case *RouteHostMatcher:
+		constraints.hosts = typedMatcher.Hosts()
+	case *RouteMethodMatcher:
+		constraints.methods = typedMatcher.methods
+	case *AllOfMatcher:
+		for _, child := range typedMatcher.matchers {
+			constraints.merge(deriveRouteConstraints(child))
+		}
+	}
+
+	return constraints
+}
+
+func (c *routeConstraints) merge(other *routeConstraints) {
+	if len(other.pathPrefix) > len(c.pathPrefix) {
+		c.pathPrefix = other.pathPrefix
+	}
+
+	if other.hosts != nil && c.hosts == nil {
+		c.hosts = other.hosts
+	}
+
+	if other.methods != nil && c.methods == nil {
+		c.methods = other.methods
+	}
+}
+
+// overlaps reports whether a request could satisfy both constraints
+func (c *routeConstraints) overlaps(other *routeConstraints) bool {
+	if !strings.HasPrefix(c.pathPrefix, other.pathPrefix) && !strings.HasPrefix(other.pathPrefix, c.pathPrefix) {
+		return false
+	}
+
+	if c.methods != nil && other.methods != nil && !slices.ContainsFunc(c.methods, func(method string) bool {
+		return slices.Contains(other.methods, method)
+	}) {
+		return false
+	}
+
+	if c.hosts == nil || other.hosts == nil {
+		return true
+	}
+
+	for _, host := range c.hosts {
+		for _, otherHost := range other.hosts {
+			if hostPatternsOverlap(host, otherHost) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hostPatternsOverlap(host string, otherHost string) bool {
+	if host == otherHost {
+		return true
+	}
+
+	hostIsWildcard := strings.HasPrefix(host, wildcardHostPrefix)
+	otherHostIsWildcard := strings.HasPrefix(otherHost, wildcardHostPrefix)
+
+	switch {
+	case hostIsWildcard && otherHostIsWildcard:
+		return strings.HasSuffix(host, strings.TrimPrefix(otherHost, "*")) || strings.HasSuffix(otherHost, strings.TrimPrefix(host, "*"))
+	case hostIsWildcard:
+		return strings.HasSuffix(otherHost, strings.TrimPrefix(host, "*"))
+	case otherHostIsWildcard:
+		return strings.HasSuffix(host, strings.TrimPrefix(otherHost, "*"))
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package reverse_proxy
+
+import (
+	"net/http"
+)
+
+// RouteCookiesMatcher matches when every cookie is present with a value matching its regex, an empty regex only requires the cookie presence
+type RouteCookiesMatcher struct {
+	cookies []*namedRegexp
+}
+
+func CreateRouteCookiesMatcher(cookies map[string]string) (*RouteCookiesMatcher, error) {
+	compiledCookies, err := compileNamedRegexps(cookies, func(name string) string { return name })
+	if err != nil {
+		return nil, err
+	}
+
+	return &RouteCookiesMatcher{
+		cookies: compiledCookies,
+	}, nil
+}
+
+func (m *RouteCookiesMatcher) Match(r *http.Request) bool {
+	for _, cookie := range m.cookies {
+		requestCookie, err := r.Cookie(cookie.name)
+		if err != nil {
+			return false
+		}
+
+		if !cookie.regex.MatchString(requestCookie.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *RouteCookiesMatcher) Specificity() int {
+	return len(m.cookies)
+}
+
+func (m *RouteCookiesMatcher) String() string {
+	return describeNamedRegexps("Cookie", m.cookies)
+}
@@ -0,0 +1,74 @@
+package reverse_proxy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteCookiesMatcher(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Matcher        Matcher
+		Cookies        map[string]string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "should forward to application when cookie is present",
+			Matcher:        CreateTestCookiesMatcher(map[string]string{"session": ""}),
+			Cookies:        map[string]string{"session": "abc"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when cookie value match",
+			Matcher:        CreateTestCookiesMatcher(map[string]string{"canary": "^(true|1)$"}),
+			Cookies:        map[string]string{"canary": "1", "session": "abc"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when cookie value does not match",
+			Matcher:        CreateTestCookiesMatcher(map[string]string{"canary": "^(true|1)$"}),
+			Cookies:        map[string]string{"canary": "0"},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should not forward to application when cookie is missing",
+			Matcher:        CreateTestCookiesMatcher(map[string]string{"session": ""}),
+			Cookies:        map[string]string{"other": "abc"},
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			for name, value := range test.Cookies {
+				request.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+}
+
+func TestRouteCookiesMatcherCreation(t *testing.T) {
+	_, err := CreateRouteCookiesMatcher(map[string]string{"session": "(invalid"})
+	assert.Error(t, err)
+}
+
+func CreateTestCookiesMatcher(cookies map[string]string) Matcher {
+	matcher, _ := CreateRouteCookiesMatcher(cookies)
+	return matcher
+}
@@ -1,45 +1,96 @@
 package reverse_proxy
 
 import (
+	"fmt"
 	"net/http"
 	"regexp"
+	"slices"
+	"strings"
 )
 
 type RouteHeadersMatcher struct {
-	headers map[string]*regexp.Regexp
+	headers []*namedRegexp
+}
+
+type namedRegexp struct {
+	name  string
+	regex *regexp.Regexp
 }
 
 func CreateRouteHeadersMatcher(headers map[string]string) (*RouteHeadersMatcher, error) {
-	matcher := &RouteHeadersMatcher{
-		headers: make(map[string]*regexp.Regexp),
+	compiledHeaders, err := compileNamedRegexps(headers, http.CanonicalHeaderKey)
+	if err != nil {
+		return nil, err
 	}
 
-	var err error = nil
-	for headerName, headerValue := range headers {
-		compiledRegex, err := regexp.Compile(headerValue)
-		if err != nil {
-			break
+	return &RouteHeadersMatcher{
+		headers: compiledHeaders,
+	}, nil
+}
+
+func (m *RouteHeadersMatcher) Match(r *http.Request) bool {
+	for _, header := range m.headers {
+		headerValues, ok := r.Header[header.name]
+		if !ok || len(headerValues) == 0 {
+			return false
 		}
 
-		matcher.headers[headerName] = compiledRegex
+		if !header.regex.MatchString(headerValues[0]) {
+			return false
+		}
 	}
 
-	return matcher, err
+	return true
 }
 
-func (m *RouteHeadersMatcher) Match(r *http.Request) bool {
-	match := false
-	for headerName, headerRegex := range m.headers {
-		headerValue := r.Header.Get(headerName)
-		if headerValue == "" {
-			break
-		}
+func (m *RouteHeadersMatcher) Specificity() int {
+	return len(m.headers)
+}
+
+func (m *RouteHeadersMatcher) String() string {
+	return describeNamedRegexps("Header", m.headers)
+}
+
+func describeNamedRegexps(function string, values []*namedRegexp) string {
+	if len(values) == 0 {
+		return "true"
+	}
 
-		match = headerRegex.MatchString(headerValue)
-		if !match {
-			break
+	descriptions := make([]string, len(values))
+	for i, value := range values {
+		descriptions[i] = fmt.Sprintf("%s(%q, %q)", function, value.name, value.regex.String())
+	}
+
+	if len(descriptions) == 1 {
+		return descriptions[0]
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(descriptions, " && "))
+}
+
+// compileNamedRegexps returns the compiled regexps ordered by name so matching does not depend on map iteration order
+func compileNamedRegexps(values map[string]string, normalizeName func(string) string) ([]*namedRegexp, error) {
+	compiledValues := make([]*namedRegexp, 0, len(values))
+	for name, value := range values {
+		compiledRegex, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
 		}
+
+		compiledValues = append(compiledValues, &namedRegexp{name: normalizeName(name), regex: compiledRegex})
 	}
 
-	return match
-}
\ No newline at end of file
+	slices.SortFunc(compiledValues, func(a, b *namedRegexp) int {
+		if a.name < b.name {
+			return -1
+		}
+
+		if a.name > b.name {
+			return 1
+		}
+
+		return 0
+	})
+
+	return compiledValues, nil
+}
@@ -44,6 +44,19 @@ func TestRouteHeadersMatcher(t *testing.T) {
 			Headers:        map[string]string{"name": "valid", "otherName": "not-valid"},
 			ExpectedStatus: http.StatusNotFound,
 		},
+		{
+			Name:           "should not forward to application when a required header is missing",
+			Matcher:        CreateTestHeadersMatcher(map[string]string{"name": "^valid$", "otherName": ".*"}),
+			Url:            endpointUrl,
+			Headers:        map[string]string{"name": "valid"},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when no header is required",
+			Matcher:        CreateTestHeadersMatcher(map[string]string{}),
+			Url:            endpointUrl,
+			ExpectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, test := range testCases {
@@ -52,7 +65,7 @@ func TestRouteHeadersMatcher(t *testing.T) {
 
 			// arrange
 			reverseProxy := createTestReverseProxy()
-			reverseProxy.registerTestApplicationAndWait([]Matcher{test.Matcher}, handlerWithRequestAsResponseContent())
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
 
 			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
 			for headerName, headerValue := range test.Headers {
@@ -69,6 +82,18 @@ func TestRouteHeadersMatcher(t *testing.T) {
 	}
 }
 
+func TestRouteHeadersMatcherCreation(t *testing.T) {
+	t.Run("should return an error when a header regex is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		// act
+		_, err := CreateRouteHeadersMatcher(map[string]string{"name": "^valid$", "otherName": "(invalid"})
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
 func CreateTestHeadersMatcher(headers map[string]string) Matcher {
 	matcher, _ := CreateRouteHeadersMatcher(headers)
 	return matcher
@@ -0,0 +1,134 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"golang.org/x/net/idna"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const wildcardHostPrefix = "*."
+
+type RouteHostMatcher struct {
+	exactHosts       map[string]struct{}
+	wildcardSuffixes map[string]struct{}
+	hosts            []string
+}
+
+func CreateRouteHostMatcher(hosts []string) (*RouteHostMatcher, error) {
+	matcher := &RouteHostMatcher{
+		exactHosts:       make(map[string]struct{}),
+		wildcardSuffixes: make(map[string]struct{}),
+		hosts:            make([]string, 0, len(hosts)),
+	}
+
+	for _, host := range hosts {
+		if strings.HasPrefix(host, wildcardHostPrefix) {
+			domain, err := normalizeDomain(strings.TrimPrefix(host, wildcardHostPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid wildcard host %q: %w", host, err)
+			}
+
+			matcher.wildcardSuffixes["."+domain] = struct{}{}
+			matcher.hosts = append(matcher.hosts, wildcardHostPrefix+domain)
+			continue
+		}
+
+		domain, err := normalizeDomain(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host %q: %w", host, err)
+		}
+
+		matcher.exactHosts[domain] = struct{}{}
+		matcher.hosts = append(matcher.hosts, domain)
+	}
+
+	return matcher, nil
+}
+
+func (m *RouteHostMatcher) Match(r *http.Request) bool {
+	return m.matchHost(normalizeRequestHost(r.Host))
+}
+
+func (m *RouteHostMatcher) matchHost(host string) bool {
+	if _, ok := m.exactHosts[host]; ok {
+		return true
+	}
+
+	for i := 0; i < len(host); i++ {
+		if host[i] != '.' {
+			continue
+		}
+
+		if _, ok := m.wildcardSuffixes[host[i:]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *RouteHostMatcher) Specificity() int {
+	specificity := 0
+	for _, host := range m.hosts {
+		specificity = max(specificity, len(strings.TrimPrefix(host, "*"))+1)
+	}
+
+	return specificity
+}
+
+func (m *RouteHostMatcher) String() string {
+	return fmt.Sprintf("Host(%s)", quoteValues(m.hosts))
+}
+
+// Hosts returns the normalized host patterns, wildcard ones being prefixed by "*."
+func (m *RouteHostMatcher) Hosts() []string {
+	return m.hosts
+}
+
+func normalizeRequestHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	if isLowerAsciiHostname(host) {
+		return strings.TrimSuffix(host, ".")
+	}
+
+	domain, err := normalizeDomain(host)
+	if err != nil {
+		return strings.ToLower(host)
+	}
+
+	return domain
+}
+
+// isLowerAsciiHostname reports whether host is already in its normalized form, sparing the idna conversion
+func isLowerAsciiHostname(host string) bool {
+	if host == "" {
+		return false
+	}
+
+	for i := 0; i < len(host); i++ {
+		char := host[i]
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '-' && char != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", fmt.Errorf("empty domain")
+	}
+
+	if net.ParseIP(strings.Trim(domain, "[]")) != nil {
+		return strings.Trim(domain, "[]"), nil
+	}
+
+	return idna.Lookup.ToASCII(domain)
+}
@@ -0,0 +1,145 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteHostMatcher(t *testing.T) {
+	testCases := []struct {
+		Name, Host     string
+		Matcher        Matcher
+		ExpectedStatus int
+	}{
+		{
+			Name:           "should forward to application when host match",
+			Matcher:        CreateTestHostMatcher("api.example.com"),
+			Host:           "api.example.com",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when host match ignoring case and port",
+			Matcher:        CreateTestHostMatcher("api.example.com"),
+			Host:           "API.Example.com:8443",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when host match with trailing dot",
+			Matcher:        CreateTestHostMatcher("api.example.com"),
+			Host:           "api.example.com.",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when wildcard host match",
+			Matcher:        CreateTestHostMatcher("*.example.com"),
+			Host:           "tenant.example.com",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when wildcard host match nested subdomain",
+			Matcher:        CreateTestHostMatcher("*.example.com"),
+			Host:           "eu.tenant.example.com",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when wildcard host is the apex domain",
+			Matcher:        CreateTestHostMatcher("*.example.com"),
+			Host:           "example.com",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when unicode host match punycode pattern",
+			Matcher:        CreateTestHostMatcher("xn--bcher-kva.example"),
+			Host:           "bücher.example",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when punycode host match unicode pattern",
+			Matcher:        CreateTestHostMatcher("*.Bücher.example"),
+			Host:           "shop.xn--bcher-kva.example",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when ipv6 host match",
+			Matcher:        CreateTestHostMatcher("::1"),
+			Host:           "[::1]:8080",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when host not match",
+			Matcher:        CreateTestHostMatcher("api.example.com"),
+			Host:           "www.example.com",
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/simple-query", nil)
+			request.Host = test.Host
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+
+	t.Run("should route to applications indexed by host", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		reverseProxy := createTestReverseProxy()
+		apiHost := reverseProxy.registerTestApplicationAndWait(CreateAllOfMatcher(CreateTestHostMatcher("api.example.com"), CreateTestPathPrefixMatcher("/")), handlerWithRequestAsResponseContent())
+		tenantsHost := reverseProxy.registerTestApplicationAndWait(CreateTestHostMatcher("*.example.com"), handlerWithRequestAsResponseContent())
+		defaultHost := reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/"), handlerWithRequestAsResponseContent())
+
+		expectedHosts := map[string]string{
+			"api.example.com":  apiHost,
+			"acme.example.com": tenantsHost,
+			"www.other.com":    defaultHost,
+		}
+
+		for requestHost, expectedHost := range expectedHosts {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/simple-query", nil)
+			request.Host = requestHost
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			require.Equal(t, http.StatusOK, response.Code)
+			content := &HttpTestResponse{}
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), content))
+			assert.Equal(t, expectedHost, content.Host)
+		}
+	})
+
+	t.Run("should return an error when host is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		// act
+		_, err := CreateRouteHostMatcher([]string{"*."})
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func CreateTestHostMatcher(hosts ...string) Matcher {
+	matcher, _ := CreateRouteHostMatcher(hosts)
+	return matcher
+}
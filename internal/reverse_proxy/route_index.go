@@ -0,0 +1,246 @@
+package reverse_proxy
+
+import (
+	"math/bits"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type bitset []uint64
+
+func createBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) set(index int) {
+	b[index/64] |= 1 << (index % 64)
+}
+
+// routeIndex narrows the applications to test for a request using the constraints derived from their matchers:
+// a radix tree on path prefixes, a hash map on hosts and a bitset per method. Candidates are tested in route order
+// with their full matcher so the index returns the same application as testing every one of them
+type routeIndex struct {
+	routes           []*ProxifiedApplication
+	paths            *radixNode
+	anyPath          bitset
+	exactHosts       map[string][]int
+	wildcardSuffixes map[string][]int
+	anyHost          bitset
+	methods          map[string]bitset
+	anyMethod        bitset
+	scratchPool      sync.Pool
+}
+
+type routeIndexScratch struct {
+	paths bitset
+	hosts bitset
+}
+
+// createRouteIndex copies the routes, the applications of the proxy are shifted in place when one is mapped
+// while requests may still be looking up the previous index
+func createRouteIndex(routes []*ProxifiedApplication) *routeIndex {
+	routes = slices.Clone(routes)
+	index := &routeIndex{
+		routes:           routes,
+		paths:            &radixNode{},
+		anyPath:          createBitset(len(routes)),
+		exactHosts:       make(map[string][]int),
+		wildcardSuffixes: make(map[string][]int),
+		anyHost:          createBitset(len(routes)),
+		methods:          make(map[string]bitset),
+		anyMethod:        createBitset(len(routes)),
+	}
+
+	index.scratchPool.New = func() any {
+		return &routeIndexScratch{
+			paths: createBitset(len(routes)),
+			hosts: createBitset(len(routes)),
+		}
+	}
+
+	for id, route := range routes {
+		index.add(id, route.constraints)
+	}
+
+	for _, methodRoutes := range index.methods {
+		for word := range methodRoutes {
+			methodRoutes[word] |= index.anyMethod[word]
+		}
+	}
+
+	return index
+}
+
+func (i *routeIndex) add(id int, constraints *routeConstraints) {
+	// every path starts with "/", keeping such routes out of the tree spares marking them on each lookup
+	if constraints.pathPrefix == "" || constraints.pathPrefix == "/" {
+		i.anyPath.set(id)
+	} else {
+		i.paths.insert(constraints.pathPrefix, id)
+	}
+
+	if constraints.hosts == nil {
+		i.anyHost.set(id)
+	}
+
+	for _, host := range constraints.hosts {
+		if strings.HasPrefix(host, wildcardHostPrefix) {
+			suffix := strings.TrimPrefix(host, "*")
+			i.wildcardSuffixes[suffix] = append(i.wildcardSuffixes[suffix], id)
+			continue
+		}
+
+		i.exactHosts[host] = append(i.exactHosts[host], id)
+	}
+
+	if constraints.methods == nil {
+		i.anyMethod.set(id)
+	}
+
+	for _, method := range constraints.methods {
+		if _, ok := i.methods[method]; !ok {
+			i.methods[method] = createBitset(len(i.routes))
+		}
+
+		i.methods[method].set(id)
+	}
+}
+
+func (i *routeIndex) lookup(req *http.Request) *ProxifiedApplication {
+	scratch := i.scratchPool.Get().(*routeIndexScratch)
+	defer i.scratchPool.Put(scratch)
+
+	copy(scratch.paths, i.anyPath)
+	i.paths.collect(req.URL.Path, scratch.paths)
+
+	copy(scratch.hosts, i.anyHost)
+	if len(i.exactHosts) > 0 || len(i.wildcardSuffixes) > 0 {
+		i.collectHosts(normalizeRequestHost(req.Host), scratch.hosts)
+	}
+
+	methods, ok := i.methods[req.Method]
+	if !ok {
+		methods = i.anyMethod
+	}
+
+	for word := range scratch.paths {
+		candidates := scratch.paths[word] & scratch.hosts[word] & methods[word]
+		for candidates != 0 {
+			route := i.routes[word*64+bits.TrailingZeros64(candidates)]
+			if route.Match(req) {
+				return route
+			}
+
+			candidates &= candidates - 1
+		}
+	}
+
+	return nil
+}
+
+func (i *routeIndex) collectHosts(host string, hosts bitset) {
+	for _, id := range i.exactHosts[host] {
+		hosts.set(id)
+	}
+
+	for position := 0; position < len(host); position++ {
+		if host[position] != '.' {
+			continue
+		}
+
+		for _, id := range i.wildcardSuffixes[host[position:]] {
+			hosts.set(id)
+		}
+	}
+}
+
+type radixNode struct {
+	label    string
+	indices  []byte
+	children []*radixNode
+	routes   []int
+}
+
+func (n *radixNode) insert(path string, id int) {
+	node := n
+	for {
+		if path == "" {
+			node.routes = append(node.routes, id)
+			return
+		}
+
+		childIndex := node.childIndex(path[0])
+		if childIndex < 0 {
+			node.indices = append(node.indices, path[0])
+			node.children = append(node.children, &radixNode{label: path, routes: []int{id}})
+			return
+		}
+
+		child := node.children[childIndex]
+		common := commonPrefixLength(path, child.label)
+		if common < len(child.label) {
+			split := &radixNode{
+				label:    child.label[:common],
+				indices:  []byte{child.label[common]},
+				children: []*radixNode{child},
+			}
+
+			child.label = child.label[common:]
+			node.children[childIndex] = split
+			child = split
+		}
+
+		path = path[common:]
+		node = child
+	}
+}
+
+// collect marks the routes whose path prefix is a prefix of path
+func (n *radixNode) collect(path string, routes bitset) {
+	node := n
+	for {
+		for _, id := range node.routes {
+			routes.set(id)
+		}
+
+		if path == "" {
+			return
+		}
+
+		childIndex := node.childIndex(path[0])
+		if childIndex < 0 {
+			return
+		}
+
+		child := node.children[childIndex]
+		if !strings.HasPrefix(path, child.label) {
+			return
+		}
+
+		path = path[len(child.label):]
+		node = child
+	}
+}
+
+func (n *radixNode) childIndex(char byte) int {
+	for i, index := range n.indices {
+		if index == char {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func commonPrefixLength(a string, b string) int {
+	length := min(len(a), len(b))
+	for i := 0; i < length; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+
+	return length
+}
@@ -0,0 +1,198 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRouteIndex(t *testing.T) {
+	// arrange
+	proxy := createTestReverseProxy()
+	routes := []testRoute{
+		{"catch-all", CreateTestPathPrefixMatcher("/"), 0},
+		{"api", CreateTestPathPrefixMatcher("/api"), 0},
+		{"api-v2", CreateTestPathPrefixMatcher("/api/v2"), 0},
+		{"api-v2-post", CreateAllOfMatcher(CreateTestPathPrefixMatcher("/api/v2"), CreateTestMethodsMatcher(http.MethodPost)), 0},
+		{"apis", CreateTestPathPrefixMatcher("/apis"), 0},
+		{"users", CreateTestPathTemplateMatcher("/users/{id}"), 0},
+		{"example-host", CreateAllOfMatcher(CreateTestHostMatcher("example.com"), CreateTestPathPrefixMatcher("/api")), 0},
+		{"tenants", CreateAllOfMatcher(CreateTestHostMatcher("*.example.com"), CreateTestPathPrefixMatcher("/")), 0},
+		{"debug", CreateAnyOfMatcher(CreateTestHeadersMatcher(map[string]string{"X-Debug": "1"}), CreateTestQueryParamsMatcher(map[string]string{"debug": "1"})), 0},
+		{"not-get", CreateAllOfMatcher(CreateNotMatcher(CreateTestMethodsMatcher(http.MethodGet)), CreateTestPathPrefixMatcher("/admin")), 0},
+		{"deletes", CreateTestMethodsMatcher(http.MethodDelete), 5},
+	}
+
+	for _, route := range routes {
+		proxy.mapTestRoute(route)
+	}
+
+	requests := []struct {
+		Method, Url string
+		Headers     map[string]string
+	}{
+		{http.MethodGet, "http://localhost/", nil},
+		{http.MethodGet, "http://localhost/api", nil},
+		{http.MethodGet, "http://localhost/ap", nil},
+		{http.MethodGet, "http://localhost/api/v2/users", nil},
+		{http.MethodPost, "http://localhost/api/v2/users", nil},
+		{http.MethodGet, "http://localhost/apis/users", nil},
+		{http.MethodGet, "http://localhost/users/42", nil},
+		{http.MethodGet, "http://localhost/users/42/orders", nil},
+		{http.MethodGet, "http://example.com/api/users", nil},
+		{http.MethodGet, "http://EXAMPLE.com:8080/api/users", nil},
+		{http.MethodGet, "http://tenant.example.com/orders", nil},
+		{http.MethodGet, "http://deep.tenant.example.com/orders", nil},
+		{http.MethodGet, "http://localhost/orders?debug=1", nil},
+		{http.MethodGet, "http://localhost/orders", map[string]string{"X-Debug": "1"}},
+		{http.MethodGet, "http://localhost/admin", nil},
+		{http.MethodPut, "http://localhost/admin", nil},
+		{http.MethodDelete, "http://localhost/api", nil},
+	}
+
+	for _, request := range requests {
+		t.Run(request.Method+" "+request.Url, func(t *testing.T) {
+			req := httptest.NewRequest(request.Method, request.Url, nil)
+			for name, value := range request.Headers {
+				req.Header.Set(name, value)
+			}
+
+			// act
+			application, err := proxy.getMatchingApplication(req)
+
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, linearMatch(proxy, req).Name, application.Name)
+		})
+	}
+}
+
+func TestRouteIndexShouldBeRebuiltWhenApplicationIsMapped(t *testing.T) {
+	// arrange
+	proxy := createTestReverseProxy()
+	proxy.mapTestRoute(testRoute{"api", CreateTestPathPrefixMatcher("/api"), 0})
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v2", nil)
+	_, err := proxy.getMatchingApplication(req)
+	require.NoError(t, err)
+
+	// act
+	proxy.mapTestRoute(testRoute{"api-v2", CreateTestPathPrefixMatcher("/api/v2"), 0})
+	application, err := proxy.getMatchingApplication(req)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "api-v2", application.Name)
+}
+
+func TestRouteIndexShouldKeepRoutesOfApplicationsMappedAfterIt(t *testing.T) {
+	// arrange
+	proxy := createTestReverseProxy()
+	proxy.mapTestRoute(testRoute{"stable", CreateTestPathPrefixMatcher("/stable"), 1})
+	proxy.applications = slices.Grow(proxy.applications, 1)
+	index := proxy.getRouteIndex()
+
+	// act
+	proxy.mapTestRoute(testRoute{"prioritized", CreateTestPathPrefixMatcher("/prioritized"), 1000})
+	application := index.lookup(httptest.NewRequest(http.MethodGet, "http://localhost/stable/users", nil))
+
+	// assert
+	require.NotNil(t, application)
+	assert.Equal(t, "stable", application.Name)
+}
+
+func TestRouteIndexShouldNotBeAffectedByApplicationsMappedDuringLookups(t *testing.T) {
+	// arrange
+	proxy := createTestReverseProxy()
+	proxy.mapTestRoute(testRoute{"stable", CreateTestPathPrefixMatcher("/stable"), 1})
+
+	done := make(chan struct{})
+	var lookups sync.WaitGroup
+	var misroutedRequests atomic.Int32
+	for range 4 {
+		lookups.Add(1)
+		go func() {
+			defer lookups.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/stable/users", nil)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if application, err := proxy.getMatchingApplication(req); err != nil || application.Name != "stable" {
+					misroutedRequests.Add(1)
+				}
+			}
+		}()
+	}
+
+	// act
+	// the routes with a higher priority are inserted in front of the stable one, shifting it
+	for i := range 500 {
+		proxy.mapTestRoute(testRoute{fmt.Sprintf("route-%d", i), CreateTestPathPrefixMatcher(fmt.Sprintf("/route-%d", i)), 1000 + i})
+	}
+
+	close(done)
+	lookups.Wait()
+
+	// assert
+	assert.Zero(t, misroutedRequests.Load())
+}
+
+func BenchmarkRouteIndex(b *testing.B) {
+	proxy := createTestReverseProxy()
+	for i := range 10000 {
+		var matcher Matcher
+		switch i % 4 {
+		case 0:
+			matcher = CreateTestPathPrefixMatcher(fmt.Sprintf("/service-%d/", i))
+		case 1:
+			matcher = CreateAllOfMatcher(CreateTestHostMatcher(fmt.Sprintf("tenant-%d.example.com", i)), CreateTestPathPrefixMatcher("/"))
+		case 2:
+			matcher = CreateAllOfMatcher(CreateTestPathTemplateMatcher(fmt.Sprintf("/resources-%d/{id}", i)), CreateTestMethodsMatcher(http.MethodGet))
+		case 3:
+			matcher = CreateAllOfMatcher(CreateTestPathPrefixMatcher(fmt.Sprintf("/api/v%d", i)), CreateTestMethodsMatcher(http.MethodPost))
+		}
+
+		proxy.mapTestRoute(testRoute{fmt.Sprintf("route-%d", i), matcher, 0})
+	}
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "http://localhost/service-4000/users", nil),
+		httptest.NewRequest(http.MethodGet, "http://tenant-5001.example.com/orders", nil),
+		httptest.NewRequest(http.MethodGet, "http://localhost/resources-9998/42", nil),
+		httptest.NewRequest(http.MethodPost, "http://localhost/api/v7", nil),
+	}
+
+	for _, req := range requests {
+		if _, err := proxy.getMatchingApplication(req); err != nil {
+			b.Fatalf("no application matching %s", req.URL)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = proxy.getMatchingApplication(requests[i%len(requests)])
+	}
+}
+
+func linearMatch(proxy *ReverseProxy, req *http.Request) *ProxifiedApplication {
+	for _, application := range proxy.applications {
+		if application.Match(req) {
+			return application
+		}
+	}
+
+	return nil
+}
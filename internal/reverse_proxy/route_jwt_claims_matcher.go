@@ -0,0 +1,89 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"github.com/noelmugnier/goprx/internal/core"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RouteJwtClaimsMatcher matches the claims of the request bearer token,
+// every claim must have one of its expected values, array claims match when one of their elements does
+type RouteJwtClaimsMatcher struct {
+	claims   []*expectedClaim
+	verifier core.JwtVerifier
+}
+
+type expectedClaim struct {
+	name   string
+	values []string
+}
+
+// CreateRouteJwtClaimsMatcher creates a matcher trusting the token signature when verifier is nil
+func CreateRouteJwtClaimsMatcher(claims map[string][]string, verifier core.JwtVerifier) (*RouteJwtClaimsMatcher, error) {
+	expectedClaims := make([]*expectedClaim, 0, len(claims))
+	for name, values := range claims {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("claim %q has no expected value", name)
+		}
+
+		expectedClaims = append(expectedClaims, &expectedClaim{name: name, values: values})
+	}
+
+	slices.SortFunc(expectedClaims, func(a, b *expectedClaim) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	return &RouteJwtClaimsMatcher{
+		claims:   expectedClaims,
+		verifier: verifier,
+	}, nil
+}
+
+func (m *RouteJwtClaimsMatcher) Match(r *http.Request) bool {
+	rawToken, ok := core.BearerToken(r)
+	if !ok {
+		return false
+	}
+
+	token, err := core.ParseJwt(rawToken)
+	if err != nil || token.ValidateTime(time.Now(), 0) != nil {
+		return false
+	}
+
+	if m.verifier != nil && m.verifier.Verify(token) != nil {
+		return false
+	}
+
+	for _, claim := range m.claims {
+		values, ok := token.ClaimValues(claim.name)
+		if !ok || !slices.ContainsFunc(values, func(value string) bool { return slices.Contains(claim.values, value) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *RouteJwtClaimsMatcher) Specificity() int {
+	return len(m.claims)
+}
+
+func (m *RouteJwtClaimsMatcher) String() string {
+	if len(m.claims) == 0 {
+		return "true"
+	}
+
+	descriptions := make([]string, len(m.claims))
+	for i, claim := range m.claims {
+		descriptions[i] = fmt.Sprintf("Claim(%q, %s)", claim.name, quoteValues(claim.values))
+	}
+
+	if len(descriptions) == 1 {
+		return descriptions[0]
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(descriptions, " && "))
+}
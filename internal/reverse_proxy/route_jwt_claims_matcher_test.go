@@ -0,0 +1,116 @@
+package reverse_proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouteJwtClaimsMatcher(t *testing.T) {
+	secret := []byte("secret")
+	premiumClaims := map[string][]string{"tenant": {"acme"}, "plan": {"pro", "enterprise"}}
+
+	testCases := []struct {
+		Name           string
+		Matcher        Matcher
+		Authorization  string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "should forward to application when claims match",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": "pro"}, secret),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should forward to application when array claim contains expected value",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": []string{"free", "enterprise"}}, secret),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when claim value is not expected",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": "free"}, secret),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should not forward to application when claim is missing",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme"}, secret),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should not forward to application without bearer token",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Basic dXNlcjpwYXNz",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should not forward to application when token is expired",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, nil),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": "pro", "exp": time.Now().Add(-time.Minute).Unix()}, secret),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when signature is verified",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, core.CreateHmacJwtVerifier(secret)),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": "pro"}, secret),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not forward to application when signature is invalid",
+			Matcher:        CreateTestJwtClaimsMatcher(premiumClaims, core.CreateHmacJwtVerifier(secret)),
+			Authorization:  "Bearer " + createTestJwt(map[string]any{"tenant": "acme", "plan": "pro"}, []byte("forged")),
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			request.Header.Set("Authorization", test.Authorization)
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, test.ExpectedStatus, response.Code)
+		})
+	}
+}
+
+func TestRouteJwtClaimsMatcherCreation(t *testing.T) {
+	_, err := CreateRouteJwtClaimsMatcher(map[string][]string{"plan": {}}, nil)
+	assert.Error(t, err)
+}
+
+func CreateTestJwtClaimsMatcher(claims map[string][]string, verifier core.JwtVerifier) Matcher {
+	matcher, _ := CreateRouteJwtClaimsMatcher(claims, verifier)
+	return matcher
+}
+
+func createTestJwt(claims map[string]any, secret []byte) string {
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,7 @@
 package reverse_proxy
 
 import (
+	"fmt"
 	"net/http"
 	"slices"
 )
@@ -19,4 +20,12 @@ func CreateRouteMethodsMatcher(methods []string) (*RouteMethodMatcher, error) {
 
 func (m *RouteMethodMatcher) Match(r *http.Request) bool {
 	return slices.Contains(m.methods, r.Method)
+}
+
+func (m *RouteMethodMatcher) Specificity() int {
+	return 1
+}
+
+func (m *RouteMethodMatcher) String() string {
+	return fmt.Sprintf("Method(%s)", quoteValues(m.methods))
 }
\ No newline at end of file
@@ -45,7 +45,7 @@ func TestRouteMethodsMatcher(t *testing.T) {
 
 			// arrange
 			reverseProxy := createTestReverseProxy()
-			reverseProxy.registerTestApplicationAndWait([]Matcher{test.Matcher}, handlerWithRequestAsResponseContent())
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
 
 			request := httptest.NewRequest(test.Method, test.Url, nil)
 			response := httptest.NewRecorder()
@@ -0,0 +1,33 @@
+package reverse_proxy
+
+import (
+	"maps"
+	"net/http"
+)
+
+type paramsMatcher interface {
+	Params(r *http.Request) map[string]string
+}
+
+// extractPathParams collects the values captured by the matchers which made the request match
+func extractPathParams(matcher Matcher, r *http.Request) map[string]string {
+	switch typedMatcher := matcher.(type) {
+	case paramsMatcher:
+		return typedMatcher.Params(r)
+	case *AllOfMatcher:
+		params := make(map[string]string)
+		for _, child := range typedMatcher.matchers {
+			maps.Copy(params, extractPathParams(child, r))
+		}
+
+		return params
+	case *AnyOfMatcher:
+		for _, child := range typedMatcher.matchers {
+			if child.Match(r) {
+				return extractPathParams(child, r)
+			}
+		}
+	}
+
+	return nil
+}
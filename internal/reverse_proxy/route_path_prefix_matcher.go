@@ -3,23 +3,41 @@ package reverse_proxy
 import (
 	"fmt"
 	"net/http"
-	"regexp"
+	"strings"
 )
 
 type RoutePathPrefixMatcher struct {
-	prefixRegex *regexp.Regexp
+	prefix string
 }
 
 func CreateRoutePathPrefixMatcher(prefix string) (*RoutePathPrefixMatcher, error) {
-	compiledRegex, err := regexp.Compile(fmt.Sprintf("^%s", prefix))
-	if err != nil {
-		return nil, err
+	if !strings.HasPrefix(prefix, "/") {
+		return nil, fmt.Errorf("path prefix %q must start with /", prefix)
 	}
+
 	return &RoutePathPrefixMatcher{
-		prefixRegex: compiledRegex,
+		prefix: prefix,
 	}, nil
 }
 
 func (m *RoutePathPrefixMatcher) Match(r *http.Request) bool {
-	return m.prefixRegex.MatchString(r.URL.Path)
+	return hasPathSegmentPrefix(r.URL.Path, m.prefix)
+}
+
+// hasPathSegmentPrefix reports whether the path starts with the prefix on a segment boundary,
+// so /v1 matches /v1 and /v1/users but not /v10
+func hasPathSegmentPrefix(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+func (m *RoutePathPrefixMatcher) Specificity() int {
+	return len(m.prefix) + 1
+}
+
+func (m *RoutePathPrefixMatcher) String() string {
+	return fmt.Sprintf("PathPrefix(%q)", m.prefix)
 }
\ No newline at end of file
@@ -18,7 +18,7 @@ func TestRoutePathPrefixMatcher(t *testing.T) {
 	}{
 		{
 			Name:           "should forward to application when path match",
-			Matcher:        CreateTestPathPrefixMatcher("/simple-query$"),
+			Matcher:        CreateTestPathPrefixMatcher("/simple-query"),
 			Url:            endpointUrl,
 			ExpectedStatus: http.StatusOK,
 		},
@@ -34,6 +34,30 @@ func TestRoutePathPrefixMatcher(t *testing.T) {
 			Url:            endpointUrl,
 			ExpectedStatus: http.StatusNotFound,
 		},
+		{
+			Name:           "should not forward to application when path prefix ends within a segment",
+			Matcher:        CreateTestPathPrefixMatcher("/v1"),
+			Url:            "http://localhost/v10/users",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when path prefix ends with a slash",
+			Matcher:        CreateTestPathPrefixMatcher("/v1/"),
+			Url:            "http://localhost/v1/users",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "should not interpret path prefix as a regex",
+			Matcher:        CreateTestPathPrefixMatcher("/v1.0"),
+			Url:            "http://localhost/v1x0/users",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when path prefix with special characters match",
+			Matcher:        CreateTestPathPrefixMatcher("/v1.0"),
+			Url:            "http://localhost/v1.0/users",
+			ExpectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, test := range testCases {
@@ -42,7 +66,7 @@ func TestRoutePathPrefixMatcher(t *testing.T) {
 
 			// arrange
 			reverseProxy := createTestReverseProxy()
-			reverseProxy.registerTestApplicationAndWait([]Matcher{test.Matcher}, handlerWithRequestAsResponseContent())
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
 
 			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
 			response := httptest.NewRecorder()
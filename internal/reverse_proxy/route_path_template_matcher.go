@@ -0,0 +1,159 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var paramNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RoutePathTemplateMatcher matches paths like /users/{id}/orders/{orderId:[0-9]+} or /static/{rest...},
+// {name} captures a single segment, {name:regex} a value matching regex and {name...} the remaining path
+type RoutePathTemplateMatcher struct {
+	template      string
+	literalPrefix string
+	literalLength int
+	params        []string
+	templateRegex *regexp.Regexp
+}
+
+func CreateRoutePathTemplateMatcher(template string) (*RoutePathTemplateMatcher, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", template)
+	}
+
+	matcher := &RoutePathTemplateMatcher{
+		template: template,
+		params:   make([]string, 0),
+	}
+
+	pattern := strings.Builder{}
+	pattern.WriteString("^")
+
+	literalPrefixDone := false
+	for position := 0; position < len(template); {
+		if template[position] != '{' {
+			end := strings.IndexByte(template[position:], '{')
+			if end < 0 {
+				end = len(template)
+			} else {
+				end += position
+			}
+
+			literal := template[position:end]
+			if strings.ContainsRune(literal, '}') {
+				return nil, fmt.Errorf("unexpected } at position %d in path template %q", position+strings.IndexByte(literal, '}'), template)
+			}
+
+			if !literalPrefixDone {
+				matcher.literalPrefix += literal
+			}
+
+			matcher.literalLength += len(literal)
+			pattern.WriteString(regexp.QuoteMeta(literal))
+			position = end
+			continue
+		}
+
+		literalPrefixDone = true
+		end, err := findClosingBrace(template, position)
+		if err != nil {
+			return nil, err
+		}
+
+		paramPattern, err := matcher.compileParam(template[position+1:end], end == len(template)-1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter at position %d in path template %q: %w", position, template, err)
+		}
+
+		pattern.WriteString(paramPattern)
+		position = end + 1
+	}
+
+	pattern.WriteString("$")
+
+	compiledRegex, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template %q: %w", template, err)
+	}
+
+	matcher.templateRegex = compiledRegex
+	return matcher, nil
+}
+
+func (m *RoutePathTemplateMatcher) compileParam(param string, last bool) (string, error) {
+	name, constraint, hasConstraint := strings.Cut(param, ":")
+
+	if strings.HasSuffix(name, "...") && !hasConstraint {
+		name = strings.TrimSuffix(name, "...")
+		if !last {
+			return "", fmt.Errorf("catch-all parameter %q must be the last element", name)
+		}
+
+		constraint = ".*"
+	} else if !hasConstraint {
+		constraint = "[^/]+"
+	}
+
+	if !paramNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid parameter name %q", name)
+	}
+
+	if _, err := regexp.Compile(constraint); err != nil {
+		return "", err
+	}
+
+	for _, existingParam := range m.params {
+		if existingParam == name {
+			return "", fmt.Errorf("duplicated parameter name %q", name)
+		}
+	}
+
+	m.params = append(m.params, name)
+	return fmt.Sprintf("(?P<%s>%s)", name, constraint), nil
+}
+
+func findClosingBrace(template string, start int) (int, error) {
+	depth := 0
+	for position := start; position < len(template); position++ {
+		switch template[position] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return position, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unclosed { at position %d in path template %q", start, template)
+}
+
+func (m *RoutePathTemplateMatcher) Match(r *http.Request) bool {
+	return m.templateRegex.MatchString(r.URL.Path)
+}
+
+func (m *RoutePathTemplateMatcher) Params(r *http.Request) map[string]string {
+	submatches := m.templateRegex.FindStringSubmatch(r.URL.Path)
+	if submatches == nil {
+		return nil
+	}
+
+	params := make(map[string]string, len(m.params))
+	for _, name := range m.params {
+		params[name] = submatches[m.templateRegex.SubexpIndex(name)]
+	}
+
+	return params
+}
+
+func (m *RoutePathTemplateMatcher) Specificity() int {
+	return m.literalLength + len(m.params) + 1
+}
+
+func (m *RoutePathTemplateMatcher) String() string {
+	return fmt.Sprintf("Path(%q)", m.template)
+}
@@ -0,0 +1,156 @@
+package reverse_proxy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutePathTemplateMatcher(t *testing.T) {
+	testCases := []struct {
+		Name, Template, Url string
+		ExpectedMatch       bool
+		ExpectedParams      map[string]string
+	}{
+		{
+			Name:           "should match and capture segment params",
+			Template:       "/users/{id}/orders/{orderId}",
+			Url:            "http://localhost/users/42/orders/abc",
+			ExpectedMatch:  true,
+			ExpectedParams: map[string]string{"id": "42", "orderId": "abc"},
+		},
+		{
+			Name:           "should match param with constraint",
+			Template:       "/users/{id}/orders/{orderId:[0-9]+}",
+			Url:            "http://localhost/users/42/orders/1337",
+			ExpectedMatch:  true,
+			ExpectedParams: map[string]string{"id": "42", "orderId": "1337"},
+		},
+		{
+			Name:          "should not match param not satisfying constraint",
+			Template:      "/users/{id}/orders/{orderId:[0-9]+}",
+			Url:           "http://localhost/users/42/orders/abc",
+			ExpectedMatch: false,
+		},
+		{
+			Name:           "should match param with constraint containing braces",
+			Template:       "/codes/{code:[A-Z]{3}}",
+			Url:            "http://localhost/codes/ABC",
+			ExpectedMatch:  true,
+			ExpectedParams: map[string]string{"code": "ABC"},
+		},
+		{
+			Name:          "should not match segment param spanning several segments",
+			Template:      "/users/{id}",
+			Url:           "http://localhost/users/42/orders",
+			ExpectedMatch: false,
+		},
+		{
+			Name:           "should capture remaining path with catch-all param",
+			Template:       "/static/{rest...}",
+			Url:            "http://localhost/static/css/site.css",
+			ExpectedMatch:  true,
+			ExpectedParams: map[string]string{"rest": "css/site.css"},
+		},
+		{
+			Name:           "should capture empty remaining path with catch-all param",
+			Template:       "/static/{rest...}",
+			Url:            "http://localhost/static/",
+			ExpectedMatch:  true,
+			ExpectedParams: map[string]string{"rest": ""},
+		},
+		{
+			Name:          "should escape literal parts",
+			Template:      "/v1.0/{id}",
+			Url:           "http://localhost/v1x0/42",
+			ExpectedMatch: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			matcher, err := CreateRoutePathTemplateMatcher(test.Template)
+			require.NoError(t, err)
+			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
+
+			// act
+			match := matcher.Match(request)
+			params := matcher.Params(request)
+
+			// assert
+			assert.Equal(t, test.ExpectedMatch, match)
+			if test.ExpectedMatch {
+				assert.Equal(t, test.ExpectedParams, params)
+			}
+		})
+	}
+
+	invalidTemplates := map[string]string{
+		"should reject template not starting with slash": "users/{id}",
+		"should reject unclosed param":                   "/users/{id",
+		"should reject unexpected closing brace":         "/users/id}",
+		"should reject invalid param name":               "/users/{1d}",
+		"should reject duplicated param name":            "/users/{id}/orders/{id}",
+		"should reject catch-all param not in last":      "/static/{rest...}/file",
+		"should reject invalid param constraint":         "/users/{id:[0-9}",
+	}
+
+	for name, template := range invalidTemplates {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// act
+			_, err := CreateRoutePathTemplateMatcher(template)
+
+			// assert
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("should extract params of matching branches", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		usersMatcher, _ := CreateRoutePathTemplateMatcher("/users/{id}")
+		ordersMatcher, _ := CreateRoutePathTemplateMatcher("/orders/{orderId}")
+		tenantMatcher, _ := CreateRoutePathTemplateMatcher("/{tenant}/{rest...}")
+		matcher := CreateAllOfMatcher(CreateAnyOfMatcher(usersMatcher, ordersMatcher), tenantMatcher)
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/orders/42", nil)
+
+		// act
+		params := extractPathParams(matcher, request)
+
+		// assert
+		assert.Equal(t, map[string]string{"orderId": "42", "tenant": "orders", "rest": "42"}, params)
+	})
+
+	t.Run("should forward to application when path template match", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		matcher, _ := CreateRoutePathTemplateMatcher("/users/{id:[0-9]+}")
+		reverseProxy := createTestReverseProxy()
+		reverseProxy.registerTestApplicationAndWait(matcher, handlerWithRequestAsResponseContent())
+
+		for url, expectedStatus := range map[string]int{"http://localhost/users/42": http.StatusOK, "http://localhost/users/abc": http.StatusNotFound} {
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, request)
+
+			// assert
+			assert.Equal(t, expectedStatus, response.Code)
+		}
+	})
+}
+
+func CreateTestPathTemplateMatcher(template string) Matcher {
+	matcher, _ := CreateRoutePathTemplateMatcher(template)
+	return matcher
+}
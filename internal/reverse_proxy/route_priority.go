@@ -0,0 +1,138 @@
+package reverse_proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type specificMatcher interface {
+	Specificity() int
+}
+
+type RouteDescription struct {
+	Order            int
+	Name             string
+	Priority         int
+	ExplicitPriority bool
+	Rule             string
+}
+
+func matcherSpecificity(matcher Matcher) int {
+	if specific, ok := matcher.(specificMatcher); ok {
+		return specific.Specificity()
+	}
+
+	return 1
+}
+
+func describeMatcher(matcher Matcher) string {
+	if stringer, ok := matcher.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return fmt.Sprintf("%T", matcher)
+}
+
+func quoteValues(values []string) string {
+	quotedValues := make([]string, len(values))
+	for i, value := range values {
+		quotedValues[i] = strconv.Quote(value)
+	}
+
+	return strings.Join(quotedValues, ", ")
+}
+
+// effectivePriority is the explicit priority of the application if any, otherwise its matcher specificity
+func (a *ProxifiedApplication) effectivePriority() int {
+	if a.Priority > 0 {
+		return a.Priority
+	}
+
+	if a.matcher == nil {
+		return 0
+	}
+
+	return matcherSpecificity(a.matcher)
+}
+
+// insertApplication keeps the applications sorted by decreasing priority, after the ones mapped with the same priority
+func (r *ReverseProxy) insertApplication(application *ProxifiedApplication) {
+	priority := application.effectivePriority()
+	position, _ := slices.BinarySearchFunc(r.applications, priority, func(other *ProxifiedApplication, priority int) int {
+		if other.effectivePriority() >= priority {
+			return -1
+		}
+
+		return 1
+	})
+
+	r.applications = slices.Insert(r.applications, position, application)
+	r.index = nil
+}
+
+func (r *ReverseProxy) warnAboutAmbiguousRoutes(ctx context.Context, application *ProxifiedApplication) {
+	for _, other := range r.applications {
+		if other == application || other.effectivePriority() != application.effectivePriority() {
+			continue
+		}
+
+		if !other.constraints.overlaps(application.constraints) {
+			continue
+		}
+
+		r.logger.Log(ctx, slog.LevelWarn, "applications with the same priority may match the same requests, mapping order will decide",
+			slog.String("application_name", application.Name),
+			slog.String("conflicting_application_name", other.Name),
+			slog.Int("priority", application.effectivePriority()),
+		)
+	}
+}
+
+func (r *ReverseProxy) Routes() []RouteDescription {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	routes := make([]RouteDescription, len(r.applications))
+	for i, application := range r.applications {
+		rule := "false"
+		if application.matcher != nil {
+			rule = describeMatcher(application.matcher)
+		}
+
+		routes[i] = RouteDescription{
+			Order:            i,
+			Name:             application.Name,
+			Priority:         application.effectivePriority(),
+			ExplicitPriority: application.Priority > 0,
+			Rule:             rule,
+		}
+	}
+
+	return routes
+}
+
+func (r *ReverseProxy) DumpRoutes(w io.Writer) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tabWriter, "ORDER\tNAME\tPRIORITY\tRULE"); err != nil {
+		return err
+	}
+
+	for _, route := range r.Routes() {
+		priority := strconv.Itoa(route.Priority)
+		if !route.ExplicitPriority {
+			priority = fmt.Sprintf("%s (computed)", priority)
+		}
+
+		if _, err := fmt.Fprintf(tabWriter, "%d\t%s\t%s\t%s\n", route.Order, route.Name, priority, route.Rule); err != nil {
+			return err
+		}
+	}
+
+	return tabWriter.Flush()
+}
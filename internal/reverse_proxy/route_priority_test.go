@@ -0,0 +1,152 @@
+package reverse_proxy
+
+import (
+	"bytes"
+	"context"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoutePriority(t *testing.T) {
+	testCases := []struct {
+		Name                string
+		Routes              []testRoute
+		Method, Url         string
+		ExpectedApplication string
+	}{
+		{
+			Name: "should prefer longer path prefix whatever the mapping order",
+			Routes: []testRoute{
+				{"api", CreateTestPathPrefixMatcher("/api"), 0},
+				{"api-v2", CreateTestPathPrefixMatcher("/api/v2"), 0},
+			},
+			Method:              http.MethodGet,
+			Url:                 "http://localhost/api/v2/users",
+			ExpectedApplication: "api-v2",
+		},
+		{
+			Name: "should prefer route with more matchers",
+			Routes: []testRoute{
+				{"api", CreateTestPathPrefixMatcher("/api"), 0},
+				{"api-post", CreateAllOfMatcher(CreateTestPathPrefixMatcher("/api"), CreateTestMethodsMatcher(http.MethodPost)), 0},
+			},
+			Method:              http.MethodPost,
+			Url:                 "http://localhost/api/users",
+			ExpectedApplication: "api-post",
+		},
+		{
+			Name: "should fallback on less specific route",
+			Routes: []testRoute{
+				{"api", CreateTestPathPrefixMatcher("/api"), 0},
+				{"api-post", CreateAllOfMatcher(CreateTestPathPrefixMatcher("/api"), CreateTestMethodsMatcher(http.MethodPost)), 0},
+			},
+			Method:              http.MethodGet,
+			Url:                 "http://localhost/api/users",
+			ExpectedApplication: "api",
+		},
+		{
+			Name: "should prefer explicit priority over specificity",
+			Routes: []testRoute{
+				{"catch-all", CreateTestPathPrefixMatcher("/"), 1000},
+				{"api-v2", CreateTestPathPrefixMatcher("/api/v2"), 0},
+			},
+			Method:              http.MethodGet,
+			Url:                 "http://localhost/api/v2/users",
+			ExpectedApplication: "catch-all",
+		},
+		{
+			Name: "should keep mapping order for equal priorities",
+			Routes: []testRoute{
+				{"first", CreateTestPathPrefixMatcher("/api"), 10},
+				{"second", CreateTestPathPrefixMatcher("/api"), 10},
+			},
+			Method:              http.MethodGet,
+			Url:                 "http://localhost/api/users",
+			ExpectedApplication: "first",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// arrange
+			reverseProxy := createTestReverseProxy()
+			for _, route := range test.Routes {
+				reverseProxy.mapTestRoute(route)
+			}
+
+			request := httptest.NewRequest(test.Method, test.Url, nil)
+
+			// act
+			application, err := reverseProxy.getMatchingApplication(request)
+
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedApplication, application.Name)
+		})
+	}
+
+	t.Run("should warn when routes with equal priority may match the same request", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		logs := &bytes.Buffer{}
+		reverseProxy := CreateReverseProxy(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+		// act
+		reverseProxy.mapTestRoute(testRoute{"users", CreateTestPathPrefixMatcher("/users"), 5})
+		reverseProxy.mapTestRoute(testRoute{"orders", CreateTestPathPrefixMatcher("/orders"), 5})
+		reverseProxy.mapTestRoute(testRoute{"other-host-users", CreateAllOfMatcher(CreateTestHostMatcher("other.com"), CreateTestPathPrefixMatcher("/users")), 5})
+		reverseProxy.mapTestRoute(testRoute{"users-admin", CreateTestPathPrefixMatcher("/users/admin"), 5})
+
+		// assert
+		assert.Equal(t, 3, strings.Count(logs.String(), "may match the same requests"))
+		assert.Contains(t, logs.String(), "application_name=other-host-users conflicting_application_name=users")
+		assert.Contains(t, logs.String(), "application_name=users-admin conflicting_application_name=users")
+		assert.Contains(t, logs.String(), "application_name=users-admin conflicting_application_name=other-host-users")
+	})
+
+	t.Run("should dump resolved route order", func(t *testing.T) {
+		t.Parallel()
+
+		// arrange
+		reverseProxy := createTestReverseProxy()
+		reverseProxy.mapTestRoute(testRoute{"api", CreateTestPathPrefixMatcher("/api"), 0})
+		reverseProxy.mapTestRoute(testRoute{"api-post", CreateAllOfMatcher(CreateTestPathPrefixMatcher("/api"), CreateTestMethodsMatcher(http.MethodPost)), 0})
+		reverseProxy.mapTestRoute(testRoute{"admin", CreateNotMatcher(CreateTestMethodsMatcher(http.MethodGet)), 100})
+		output := &bytes.Buffer{}
+
+		// act
+		err := reverseProxy.DumpRoutes(output)
+
+		// assert
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Equal(t, []string{"ORDER", "NAME", "PRIORITY", "RULE"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"0", "admin", "100", `!Method("GET")`}, strings.Fields(lines[1]))
+		assert.Equal(t, []string{"1", "api-post", "6", "(computed)", `(PathPrefix("/api")`, "&&", `Method("POST"))`}, strings.Fields(lines[2]))
+		assert.Equal(t, []string{"2", "api", "5", "(computed)", `PathPrefix("/api")`}, strings.Fields(lines[3]))
+	})
+}
+
+type testRoute struct {
+	Name     string
+	Matcher  Matcher
+	Priority int
+}
+
+func (r *ReverseProxy) mapTestRoute(route testRoute) *ProxifiedApplication {
+	logger := slog.Default()
+	sbCfg := core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 0, 1)
+	sb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), sbCfg, logger)
+
+	return r.MapApplicationWithPriority(context.Background(), route.Name, route.Matcher, route.Priority, sb)
+}
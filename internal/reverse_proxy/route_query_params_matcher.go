@@ -2,44 +2,46 @@ package reverse_proxy
 
 import (
 	"net/http"
-	"regexp"
 )
 
 type RouteQueryParamsMatcher struct {
-	params map[string]*regexp.Regexp
+	params []*namedRegexp
 }
 
 func CreateRouteQueryParamsMatcher(params map[string]string) (*RouteQueryParamsMatcher, error) {
-	matcher := &RouteQueryParamsMatcher{
-		params: make(map[string]*regexp.Regexp),
+	compiledParams, err := compileNamedRegexps(params, func(name string) string { return name })
+	if err != nil {
+		return nil, err
 	}
 
-	var err error = nil
-	for paramName, paramValue := range params {
-		compiledRegex, err := regexp.Compile(paramValue)
-		if err != nil {
-			break
-		}
-
-		matcher.params[paramName] = compiledRegex
-	}
-
-	return matcher, err
+	return &RouteQueryParamsMatcher{
+		params: compiledParams,
+	}, nil
 }
 
 func (m *RouteQueryParamsMatcher) Match(r *http.Request) bool {
-	match := false
-	for paramName, paramRegex := range m.params {
-		paramValue := r.URL.Query().Get(paramName)
-		if paramValue == "" {
-			break
+	if len(m.params) == 0 {
+		return true
+	}
+
+	query := r.URL.Query()
+	for _, param := range m.params {
+		if !query.Has(param.name) {
+			return false
 		}
 
-		match = paramRegex.MatchString(paramValue)
-		if !match {
-			break
+		if !param.regex.MatchString(query.Get(param.name)) {
+			return false
 		}
 	}
 
-	return match
-}
\ No newline at end of file
+	return true
+}
+
+func (m *RouteQueryParamsMatcher) Specificity() int {
+	return len(m.params)
+}
+
+func (m *RouteQueryParamsMatcher) String() string {
+	return describeNamedRegexps("Query", m.params)
+}
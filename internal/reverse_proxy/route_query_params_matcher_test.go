@@ -40,6 +40,18 @@ func TestRouteQueryParamsMatcher(t *testing.T) {
 			Url:            fmt.Sprintf("%s?name=valid&otherName=not-valid", endpointUrl),
 			ExpectedStatus: http.StatusNotFound,
 		},
+		{
+			Name:           "should not forward to application when a required param is missing",
+			Matcher:        CreateTestQueryParamsMatcher(map[string]string{"name": "^valid$", "otherName": ".*"}),
+			Url:            fmt.Sprintf("%s?name=valid", endpointUrl),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "should forward to application when no param is required",
+			Matcher:        CreateTestQueryParamsMatcher(map[string]string{}),
+			Url:            endpointUrl,
+			ExpectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, test := range testCases {
@@ -48,7 +60,7 @@ func TestRouteQueryParamsMatcher(t *testing.T) {
 
 			// arrange
 			reverseProxy := createTestReverseProxy()
-			reverseProxy.registerTestApplicationAndWait([]Matcher{test.Matcher}, handlerWithRequestAsResponseContent())
+			reverseProxy.registerTestApplicationAndWait(test.Matcher, handlerWithRequestAsResponseContent())
 
 			request := httptest.NewRequest(http.MethodGet, test.Url, nil)
 			response := httptest.NewRecorder()
@@ -62,6 +74,18 @@ func TestRouteQueryParamsMatcher(t *testing.T) {
 	}
 }
 
+func TestRouteQueryParamsMatcherCreation(t *testing.T) {
+	t.Run("should return an error when a param regex is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		// act
+		_, err := CreateRouteQueryParamsMatcher(map[string]string{"name": "(invalid"})
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
 func CreateTestQueryParamsMatcher(params map[string]string) Matcher {
 	matcher, _ := CreateRouteQueryParamsMatcher(params)
 	return matcher
@@ -0,0 +1,179 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ruleFunction struct {
+	minArguments int
+	maxArguments int
+	compile      func(arguments []string) (Matcher, error)
+}
+
+const unlimitedRuleArguments = -1
+
+var ruleFunctions = map[string]ruleFunction{
+	"Host": {1, unlimitedRuleArguments, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteHostMatcher(arguments))
+	}},
+	"PathPrefix": {1, 1, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRoutePathPrefixMatcher(arguments[0]))
+	}},
+	"Path": {1, 1, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRoutePathTemplateMatcher(arguments[0]))
+	}},
+	"Method": {1, unlimitedRuleArguments, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteMethodsMatcher(arguments))
+	}},
+	"Header": {1, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteHeadersMatcher(namedRuleValue(arguments)))
+	}},
+	"HeaderRegexp": {2, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteHeadersMatcher(map[string]string{arguments[0]: arguments[1]}))
+	}},
+	"Query": {1, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteQueryParamsMatcher(namedRuleValue(arguments)))
+	}},
+	"QueryRegexp": {2, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteQueryParamsMatcher(map[string]string{arguments[0]: arguments[1]}))
+	}},
+	"Cookie": {1, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteCookiesMatcher(namedRuleValue(arguments)))
+	}},
+	"CookieRegexp": {2, 2, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteCookiesMatcher(map[string]string{arguments[0]: arguments[1]}))
+	}},
+	"ClientIP": {1, unlimitedRuleArguments, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteClientIpMatcher(arguments))
+	}},
+	"Claim": {2, unlimitedRuleArguments, func(arguments []string) (Matcher, error) {
+		return asMatcher(CreateRouteJwtClaimsMatcher(map[string][]string{arguments[0]: arguments[1:]}, nil))
+	}},
+}
+
+// CompileRule parses the rule and compiles it into the matchers it is made of
+func CompileRule(rule string) (Matcher, error) {
+	expression, err := ParseRule(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileRuleExpression(expression)
+}
+
+func CompileRuleExpression(expression RuleExpression) (Matcher, error) {
+	switch typedExpression := expression.(type) {
+	case *RuleAnd:
+		matchers, err := compileRuleOperands(typedExpression.Operands)
+		if err != nil {
+			return nil, err
+		}
+
+		return CreateAllOfMatcher(matchers...), nil
+	case *RuleOr:
+		matchers, err := compileRuleOperands(typedExpression.Operands)
+		if err != nil {
+			return nil, err
+		}
+
+		return CreateAnyOfMatcher(matchers...), nil
+	case *RuleNot:
+		matcher, err := CompileRuleExpression(typedExpression.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		return CreateNotMatcher(matcher), nil
+	case *RuleCall:
+		return compileRuleCall(typedExpression)
+	}
+
+	return nil, fmt.Errorf("unsupported rule expression %T", expression)
+}
+
+func compileRuleOperands(operands []RuleExpression) ([]Matcher, error) {
+	matchers := make([]Matcher, len(operands))
+	for i, operand := range operands {
+		matcher, err := CompileRuleExpression(operand)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers[i] = matcher
+	}
+
+	return matchers, nil
+}
+
+func compileRuleCall(call *RuleCall) (Matcher, error) {
+	function, ok := ruleFunctions[call.Function]
+	if !ok {
+		return nil, &RuleSyntaxError{
+			Position: call.Position(),
+			Message:  fmt.Sprintf("unknown matcher %s, expected one of %s", call.Function, strings.Join(ruleFunctionNames(), ", ")),
+		}
+	}
+
+	if len(call.Arguments) < function.minArguments || (function.maxArguments != unlimitedRuleArguments && len(call.Arguments) > function.maxArguments) {
+		return nil, &RuleSyntaxError{
+			Position: call.Position(),
+			Message:  fmt.Sprintf("%s expects %s, got %d", call.Function, describeRuleArity(function), len(call.Arguments)),
+		}
+	}
+
+	matcher, err := function.compile(call.Arguments)
+	if err != nil {
+		return nil, &RuleSyntaxError{
+			Position: call.Position(),
+			Message:  fmt.Sprintf("invalid %s: %s", call, err),
+		}
+	}
+
+	return matcher, nil
+}
+
+// asMatcher avoids returning a typed nil matcher when the constructor failed
+func asMatcher[T Matcher](matcher T, err error) (Matcher, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return matcher, nil
+}
+
+// namedRuleValue maps a name with a regex matching exactly its value, omitting the value only requires the presence of the name
+func namedRuleValue(arguments []string) map[string]string {
+	if len(arguments) == 1 {
+		return map[string]string{arguments[0]: ""}
+	}
+
+	return map[string]string{arguments[0]: "^" + regexp.QuoteMeta(arguments[1]) + "$"}
+}
+
+func describeRuleArity(function ruleFunction) string {
+	switch {
+	case function.maxArguments == unlimitedRuleArguments && function.minArguments == 1:
+		return "at least 1 argument"
+	case function.maxArguments == unlimitedRuleArguments:
+		return fmt.Sprintf("at least %d arguments", function.minArguments)
+	case function.minArguments == 1 && function.maxArguments == 1:
+		return "1 argument"
+	case function.minArguments == function.maxArguments:
+		return fmt.Sprintf("%d arguments", function.minArguments)
+	}
+
+	return fmt.Sprintf("%d to %d arguments", function.minArguments, function.maxArguments)
+}
+
+func ruleFunctionNames() []string {
+	names := make([]string, 0, len(ruleFunctions))
+	for name := range ruleFunctions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,117 @@
+package reverse_proxy
+
+import (
+	"context"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompileRule(t *testing.T) {
+	rule := `Host("api.example.com") && PathPrefix("/v2") && (Method("GET") || Header("X-Debug", "1"))`
+
+	testCases := []struct {
+		Name, Rule, Method, Url string
+		Headers                 map[string]string
+		ExpectedMatch           bool
+	}{
+		{"should match all conditions", rule, http.MethodGet, "http://api.example.com/v2/users", nil, true},
+		{"should match alternative condition", rule, http.MethodPost, "http://api.example.com/v2/users", map[string]string{"X-Debug": "1"}, true},
+		{"should not match without alternative condition", rule, http.MethodPost, "http://api.example.com/v2/users", nil, false},
+		{"should not match other host", rule, http.MethodGet, "http://www.example.com/v2/users", nil, false},
+		{"should match negation", `PathPrefix("/admin") && !ClientIP("10.0.0.0/8")`, http.MethodGet, "http://localhost/admin", nil, true},
+		{"should match path template", `Path("/users/{id:[0-9]+}")`, http.MethodGet, "http://localhost/users/42", nil, true},
+		{"should match query presence", `Query("debug")`, http.MethodGet, "http://localhost/?debug", nil, true},
+		{"should match cookie value", "Cookie(`canary`, `1`)", http.MethodGet, "http://localhost/", map[string]string{"Cookie": "canary=1"}, true},
+		{"should not match header value containing the value", `Header("X-Debug", "1")`, http.MethodGet, "http://localhost/", map[string]string{"X-Debug": "10"}, false},
+		{"should not match header value surrounding the value", `Header("X-Debug", "1")`, http.MethodGet, "http://localhost/", map[string]string{"X-Debug": "x1y"}, false},
+		{"should match header value with regex characters literally", `Header("X-Version", "1.0+beta")`, http.MethodGet, "http://localhost/", map[string]string{"X-Version": "1.0+beta"}, true},
+		{"should not match header value matching the value as a regex", `Header("X-Version", "1.0")`, http.MethodGet, "http://localhost/", map[string]string{"X-Version": "1a0"}, false},
+		{"should not match query value containing the value", `Query("debug", "1")`, http.MethodGet, "http://localhost/?debug=10", nil, false},
+		{"should not match cookie value containing the value", `Cookie("canary", "1")`, http.MethodGet, "http://localhost/", map[string]string{"Cookie": "canary=x1y"}, false},
+		{"should match header regexp", `HeaderRegexp("X-Debug", "^1[0-9]*$")`, http.MethodGet, "http://localhost/", map[string]string{"X-Debug": "10"}, true},
+		{"should match query regexp", `QueryRegexp("debug", "^(1|true)$")`, http.MethodGet, "http://localhost/?debug=true", nil, true},
+		{"should match cookie regexp", "CookieRegexp(`canary`, `^[12]$`)", http.MethodGet, "http://localhost/", map[string]string{"Cookie": "canary=2"}, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// arrange
+			matcher, err := CompileRule(test.Rule)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(test.Method, test.Url, nil)
+			request.RemoteAddr = "192.168.1.1:1234"
+			for name, value := range test.Headers {
+				request.Header.Set(name, value)
+			}
+
+			// act
+			matched := matcher.Match(request)
+
+			// assert
+			assert.Equal(t, test.ExpectedMatch, matched)
+		})
+	}
+}
+
+func TestCompileRuleShouldBuildExistingMatchers(t *testing.T) {
+	// act
+	matcher, err := CompileRule(`Host("api.example.com") && PathPrefix("/v2") && (Method("GET") || Header("X-Debug", "1"))`)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, `(Host("api.example.com") && PathPrefix("/v2") && (Method("GET") || Header("X-Debug", "^1$")))`, describeMatcher(matcher))
+
+	constraints := deriveRouteConstraints(matcher)
+	assert.Equal(t, "/v2", constraints.pathPrefix)
+	assert.Equal(t, []string{"api.example.com"}, constraints.hosts)
+}
+
+func TestCompileRuleErrors(t *testing.T) {
+	testCases := []struct {
+		Name, Rule       string
+		ExpectedPosition int
+		ExpectedMessage  string
+	}{
+		{"should report unknown matcher", `Host("a") && Hots("b")`, 14, "unknown matcher Hots, expected one of Claim, ClientIP, Cookie, CookieRegexp, Header, HeaderRegexp, Host, Method, Path, PathPrefix, Query, QueryRegexp"},
+		{"should report missing argument", `PathPrefix()`, 1, "PathPrefix expects 1 argument, got 0"},
+		{"should report too many arguments", `Header("a", "b", "c")`, 1, "Header expects 1 to 2 arguments, got 3"},
+		{"should report invalid argument", `Method("GET") || PathPrefix("v2")`, 18, `invalid PathPrefix("v2"): path prefix "v2" must start with /`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// act
+			_, err := CompileRule(test.Rule)
+
+			// assert
+			var syntaxErr *RuleSyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, test.ExpectedPosition, syntaxErr.Position)
+			assert.Equal(t, test.ExpectedMessage, syntaxErr.Message)
+		})
+	}
+}
+
+func TestMapApplicationWithRule(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	logger := slog.Default()
+	sbCfg := core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 0, 1)
+	sb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), sbCfg, logger)
+
+	// act
+	application, err := reverseProxy.MapApplicationWithRule(context.Background(), "api", `PathPrefix("/api") && Method("GET")`, 0, sb)
+	_, invalidErr := reverseProxy.MapApplicationWithRule(context.Background(), "invalid", `PathPrefix("/api") &&`, 0, sb)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "api", application.Name)
+	assert.Error(t, invalidErr)
+	assert.Len(t, reverseProxy.Routes(), 1)
+}
@@ -0,0 +1,163 @@
+package reverse_proxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ruleTokenKind int
+
+const (
+	ruleTokenEOF ruleTokenKind = iota
+	ruleTokenIdentifier
+	ruleTokenString
+	ruleTokenLeftParenthesis
+	ruleTokenRightParenthesis
+	ruleTokenComma
+	ruleTokenAnd
+	ruleTokenOr
+	ruleTokenNot
+)
+
+func (k ruleTokenKind) String() string {
+	switch k {
+	case ruleTokenEOF:
+		return "end of rule"
+	case ruleTokenIdentifier:
+		return "identifier"
+	case ruleTokenString:
+		return "string"
+	case ruleTokenLeftParenthesis:
+		return `"("`
+	case ruleTokenRightParenthesis:
+		return `")"`
+	case ruleTokenComma:
+		return `","`
+	case ruleTokenAnd:
+		return `"&&"`
+	case ruleTokenOr:
+		return `"||"`
+	case ruleTokenNot:
+		return `"!"`
+	}
+
+	return "unknown token"
+}
+
+type ruleToken struct {
+	kind     ruleTokenKind
+	value    string
+	position int
+}
+
+// RuleSyntaxError reports an invalid rule, Position is the 1-based column of the offending character
+type RuleSyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *RuleSyntaxError) Error() string {
+	return fmt.Sprintf("invalid rule at position %d: %s", e.Position, e.Message)
+}
+
+func createRuleSyntaxError(offset int, format string, args ...any) *RuleSyntaxError {
+	return &RuleSyntaxError{
+		Position: offset + 1,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+type ruleLexer struct {
+	rule   string
+	offset int
+}
+
+func tokenizeRule(rule string) ([]ruleToken, error) {
+	lexer := &ruleLexer{rule: rule}
+	tokens := make([]ruleToken, 0)
+
+	for {
+		token, err := lexer.next()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+		if token.kind == ruleTokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *ruleLexer) next() (ruleToken, error) {
+	for l.offset < len(l.rule) && strings.ContainsRune(" \t\r\n", rune(l.rule[l.offset])) {
+		l.offset++
+	}
+
+	start := l.offset
+	if start == len(l.rule) {
+		return ruleToken{kind: ruleTokenEOF, position: start}, nil
+	}
+
+	switch char := l.rule[start]; {
+	case char == '(':
+		return l.emit(ruleTokenLeftParenthesis, 1), nil
+	case char == ')':
+		return l.emit(ruleTokenRightParenthesis, 1), nil
+	case char == ',':
+		return l.emit(ruleTokenComma, 1), nil
+	case char == '!':
+		return l.emit(ruleTokenNot, 1), nil
+	case strings.HasPrefix(l.rule[start:], "&&"):
+		return l.emit(ruleTokenAnd, 2), nil
+	case strings.HasPrefix(l.rule[start:], "||"):
+		return l.emit(ruleTokenOr, 2), nil
+	case char == '"' || char == '`':
+		return l.readString(char)
+	case isRuleIdentifierChar(char, true):
+		for l.offset < len(l.rule) && isRuleIdentifierChar(l.rule[l.offset], false) {
+			l.offset++
+		}
+
+		return ruleToken{kind: ruleTokenIdentifier, value: l.rule[start:l.offset], position: start}, nil
+	default:
+		return ruleToken{}, createRuleSyntaxError(start, "unexpected character %q", char)
+	}
+}
+
+func (l *ruleLexer) emit(kind ruleTokenKind, length int) ruleToken {
+	token := ruleToken{kind: kind, value: l.rule[l.offset : l.offset+length], position: l.offset}
+	l.offset += length
+	return token
+}
+
+// readString reads a double-quoted string with Go escapes or a backquoted raw string, convenient for regexps
+func (l *ruleLexer) readString(quote byte) (ruleToken, error) {
+	start := l.offset
+	for l.offset++; l.offset < len(l.rule); l.offset++ {
+		char := l.rule[l.offset]
+		if char == '\\' && quote == '"' {
+			l.offset++
+			continue
+		}
+
+		if char != quote {
+			continue
+		}
+
+		l.offset++
+		value, err := strconv.Unquote(l.rule[start:l.offset])
+		if err != nil {
+			return ruleToken{}, createRuleSyntaxError(start, "invalid string %s", l.rule[start:l.offset])
+		}
+
+		return ruleToken{kind: ruleTokenString, value: value, position: start}, nil
+	}
+
+	return ruleToken{}, createRuleSyntaxError(start, "unterminated string")
+}
+
+func isRuleIdentifierChar(char byte, first bool) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_' || (!first && char >= '0' && char <= '9')
+}
@@ -0,0 +1,237 @@
+package reverse_proxy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RuleExpression is a node of a parsed rule, String prints it back as a rule
+type RuleExpression interface {
+	Position() int
+	String() string
+}
+
+type RuleCall struct {
+	position  int
+	Function  string
+	Arguments []string
+}
+
+type RuleAnd struct {
+	position int
+	Operands []RuleExpression
+}
+
+type RuleOr struct {
+	position int
+	Operands []RuleExpression
+}
+
+type RuleNot struct {
+	position int
+	Operand  RuleExpression
+}
+
+func (c *RuleCall) Position() int { return c.position + 1 }
+func (a *RuleAnd) Position() int  { return a.position + 1 }
+func (o *RuleOr) Position() int   { return o.position + 1 }
+func (n *RuleNot) Position() int  { return n.position + 1 }
+
+func (c *RuleCall) String() string {
+	arguments := make([]string, len(c.Arguments))
+	for i, argument := range c.Arguments {
+		arguments[i] = strconv.Quote(argument)
+	}
+
+	return c.Function + "(" + strings.Join(arguments, ", ") + ")"
+}
+
+func (a *RuleAnd) String() string {
+	return formatRuleOperands(a.Operands, " && ", func(operand RuleExpression) bool {
+		_, isOr := operand.(*RuleOr)
+		return isOr
+	})
+}
+
+func (o *RuleOr) String() string {
+	return formatRuleOperands(o.Operands, " || ", func(RuleExpression) bool {
+		return false
+	})
+}
+
+func (n *RuleNot) String() string {
+	switch n.Operand.(type) {
+	case *RuleAnd, *RuleOr:
+		return "!(" + n.Operand.String() + ")"
+	}
+
+	return "!" + n.Operand.String()
+}
+
+// formatRuleOperands only parenthesizes the operands binding less tightly than the operator joining them
+func formatRuleOperands(operands []RuleExpression, operator string, needsParenthesis func(RuleExpression) bool) string {
+	formattedOperands := make([]string, len(operands))
+	for i, operand := range operands {
+		formattedOperands[i] = operand.String()
+		if needsParenthesis(operand) {
+			formattedOperands[i] = "(" + formattedOperands[i] + ")"
+		}
+	}
+
+	return strings.Join(formattedOperands, operator)
+}
+
+type ruleParser struct {
+	tokens  []ruleToken
+	current int
+}
+
+// ParseRule parses a rule such as Host("api.example.com") && (Method("GET") || Header("X-Debug", "1")),
+// && binds tighter than || and ! applies to the expression following it
+func ParseRule(rule string) (RuleExpression, error) {
+	tokens, err := tokenizeRule(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &ruleParser{tokens: tokens}
+	expression, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := parser.peek(); token.kind != ruleTokenEOF {
+		return nil, createRuleSyntaxError(token.position, "unexpected %s, expected \"&&\", \"||\" or end of rule", token.kind)
+	}
+
+	return expression, nil
+}
+
+func (p *ruleParser) peek() ruleToken {
+	return p.tokens[p.current]
+}
+
+func (p *ruleParser) advance() ruleToken {
+	token := p.tokens[p.current]
+	if token.kind != ruleTokenEOF {
+		p.current++
+	}
+
+	return token
+}
+
+func (p *ruleParser) expect(kind ruleTokenKind, context string) (ruleToken, error) {
+	token := p.advance()
+	if token.kind != kind {
+		return token, createRuleSyntaxError(token.position, "unexpected %s, expected %s %s", token.kind, kind, context)
+	}
+
+	return token, nil
+}
+
+func (p *ruleParser) parseOr() (RuleExpression, error) {
+	position := p.peek().position
+	operands, err := p.parseOperands(ruleTokenOr, p.parseAnd)
+	if err != nil || len(operands) == 1 {
+		return firstOperand(operands), err
+	}
+
+	return &RuleOr{position: position, Operands: operands}, nil
+}
+
+func (p *ruleParser) parseAnd() (RuleExpression, error) {
+	position := p.peek().position
+	operands, err := p.parseOperands(ruleTokenAnd, p.parseUnary)
+	if err != nil || len(operands) == 1 {
+		return firstOperand(operands), err
+	}
+
+	return &RuleAnd{position: position, Operands: operands}, nil
+}
+
+func (p *ruleParser) parseOperands(operator ruleTokenKind, parseOperand func() (RuleExpression, error)) ([]RuleExpression, error) {
+	operands := make([]RuleExpression, 0, 1)
+	for {
+		operand, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+		if p.peek().kind != operator {
+			return operands, nil
+		}
+
+		p.advance()
+	}
+}
+
+func firstOperand(operands []RuleExpression) RuleExpression {
+	if len(operands) == 0 {
+		return nil
+	}
+
+	return operands[0]
+}
+
+func (p *ruleParser) parseUnary() (RuleExpression, error) {
+	token := p.peek()
+	switch token.kind {
+	case ruleTokenNot:
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &RuleNot{position: token.position, Operand: operand}, nil
+	case ruleTokenLeftParenthesis:
+		p.advance()
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(ruleTokenRightParenthesis, "to close the group"); err != nil {
+			return nil, err
+		}
+
+		return expression, nil
+	case ruleTokenIdentifier:
+		return p.parseCall()
+	}
+
+	return nil, createRuleSyntaxError(token.position, "unexpected %s, expected a matcher, \"!\" or \"(\"", token.kind)
+}
+
+func (p *ruleParser) parseCall() (RuleExpression, error) {
+	function := p.advance()
+	if _, err := p.expect(ruleTokenLeftParenthesis, "after "+function.value); err != nil {
+		return nil, err
+	}
+
+	call := &RuleCall{position: function.position, Function: function.value, Arguments: make([]string, 0)}
+	if p.peek().kind == ruleTokenRightParenthesis {
+		p.advance()
+		return call, nil
+	}
+
+	for {
+		argument, err := p.expect(ruleTokenString, "as argument of "+function.value)
+		if err != nil {
+			return nil, err
+		}
+
+		call.Arguments = append(call.Arguments, argument.value)
+
+		separator := p.advance()
+		switch separator.kind {
+		case ruleTokenRightParenthesis:
+			return call, nil
+		case ruleTokenComma:
+			continue
+		}
+
+		return nil, createRuleSyntaxError(separator.position, "unexpected %s, expected \",\" or \")\" in arguments of %s", separator.kind, function.value)
+	}
+}
@@ -0,0 +1,68 @@
+package reverse_proxy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		Name, Rule, ExpectedRule string
+	}{
+		{"should parse a single matcher", `Host("api.example.com")`, `Host("api.example.com")`},
+		{"should parse several arguments", `Method( "GET" , "POST" )`, `Method("GET", "POST")`},
+		{"should parse matcher without arguments", `Host()`, `Host()`},
+		{"should give precedence to and over or", `Method("GET") || Method("HEAD") && Header("X-Debug", "1")`, `Method("GET") || Method("HEAD") && Header("X-Debug", "1")`},
+		{"should keep required parenthesis", `Host("a") && PathPrefix("/v2") && (Method("GET") || Header("X-Debug", "1"))`, `Host("a") && PathPrefix("/v2") && (Method("GET") || Header("X-Debug", "1"))`},
+		{"should drop redundant parenthesis", `((Host("a")) && (PathPrefix("/v2")))`, `Host("a") && PathPrefix("/v2")`},
+		{"should parse negations", `!Method("GET") && !(Host("a") || Host("b"))`, `!Method("GET") && !(Host("a") || Host("b"))`},
+		{"should parse raw strings", "Header(`X-Version`, `^v[0-9]+\\.\\d$`)", `Header("X-Version", "^v[0-9]+\\.\\d$")`},
+		{"should parse escaped strings", `PathPrefix("/say \"hi\"")`, `PathPrefix("/say \"hi\"")`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// act
+			expression, err := ParseRule(test.Rule)
+
+			// assert
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedRule, expression.String())
+
+			reparsed, err := ParseRule(expression.String())
+			require.NoError(t, err)
+			assert.Equal(t, expression.String(), reparsed.String())
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	testCases := []struct {
+		Name, Rule       string
+		ExpectedPosition int
+		ExpectedMessage  string
+	}{
+		{"should report empty rule", ``, 1, `unexpected end of rule, expected a matcher, "!" or "("`},
+		{"should report unexpected character", `Host("a") & Method("GET")`, 11, `unexpected character '&'`},
+		{"should report unterminated string", `Host("a) && Method(GET)`, 6, `unterminated string`},
+		{"should report missing parenthesis", `(Host("a") || Host("b")`, 24, `unexpected end of rule, expected ")" to close the group`},
+		{"should report missing operator", `Host("a") Method("GET")`, 11, `unexpected identifier, expected "&&", "||" or end of rule`},
+		{"should report unquoted argument", `Host(api)`, 6, `unexpected identifier, expected string as argument of Host`},
+		{"should report missing argument separator", `Header("X-Debug" "1")`, 18, `unexpected string, expected "," or ")" in arguments of Header`},
+		{"should report dangling operator", `Host("a") &&`, 13, `unexpected end of rule, expected a matcher, "!" or "("`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			// act
+			_, err := ParseRule(test.Rule)
+
+			// assert
+			var syntaxErr *RuleSyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, test.ExpectedPosition, syntaxErr.Position)
+			assert.Equal(t, test.ExpectedMessage, syntaxErr.Message)
+		})
+	}
+}
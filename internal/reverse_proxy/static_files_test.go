@@ -0,0 +1,100 @@
+package reverse_proxy
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapStaticFiles(t *testing.T) {
+	// arrange
+	directory := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "index.html"), []byte("<html>app</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "app.js"), []byte("console.log('app')"), 0o644))
+
+	reverseProxy := createTestReverseProxy()
+	reverseProxy.registerTestApplicationAndWait(CreateTestPathPrefixMatcher("/api"), handlerWithRequestAsResponseContent())
+	reverseProxy.MapStaticFiles(context.Background(), "frontend", CreateTestPathPrefixMatcher("/"), 0, os.DirFS(directory), &core.StaticFilesConfig{IndexFile: "index.html", SpaFallback: true})
+
+	testCases := map[string]struct {
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		"http://localhost/app.js":     {http.StatusOK, "console.log('app')"},
+		"http://localhost/users/42":   {http.StatusOK, "<html>app</html>"},
+		"http://localhost/missing.js": {http.StatusNotFound, ""},
+	}
+
+	for url, expected := range testCases {
+		request := httptest.NewRequest(http.MethodGet, url, nil)
+		request.Header.Set("Accept", "text/html")
+		if filepath.Ext(url) != "" {
+			request.Header.Set("Accept", "*/*")
+		}
+
+		response := httptest.NewRecorder()
+
+		// act
+		reverseProxy.router.ServeHTTP(response, request)
+
+		// assert
+		assert.Equal(t, expected.ExpectedStatus, response.Code, url)
+		if expected.ExpectedBody != "" {
+			assert.Equal(t, expected.ExpectedBody, response.Body.String(), url)
+		}
+	}
+
+	apiResponse := httptest.NewRecorder()
+	reverseProxy.router.ServeHTTP(apiResponse, httptest.NewRequest(http.MethodGet, "http://localhost/api/users", nil))
+
+	require.Equal(t, http.StatusOK, apiResponse.Code)
+
+	var upstreamRequest HttpTestResponse
+	require.NoError(t, json.Unmarshal(apiResponse.Body.Bytes(), &upstreamRequest))
+	assert.Equal(t, "/api/users", upstreamRequest.RequestUrl)
+}
+
+func TestMapStaticFilesOnPrefixRoute(t *testing.T) {
+	// arrange
+	directory := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "app.js"), []byte("console.log('app')"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "assets"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "assets", "app.js"), []byte("console.log('assets')"), 0o644))
+
+	stripPrefix, err := core.CreateStripPrefixRewriteRule("/assets")
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		Rules        []core.PathRewriteRule
+		ExpectedBody string
+	}{
+		"should resolve the files from the full path":     {nil, "console.log('assets')"},
+		"should resolve the files from the stripped path": {[]core.PathRewriteRule{stripPrefix}, "console.log('app')"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			reverseProxy := createTestReverseProxy()
+			application := reverseProxy.MapStaticFiles(context.Background(), "assets", CreateTestPathPrefixMatcher("/assets/"), 0, os.DirFS(directory), nil)
+			if testCase.Rules != nil {
+				application.RewritePath(testCase.Rules...)
+			}
+
+			response := httptest.NewRecorder()
+
+			// act
+			reverseProxy.router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://localhost/assets/app.js", nil))
+
+			// assert
+			require.Equal(t, http.StatusOK, response.Code)
+			assert.Equal(t, testCase.ExpectedBody, response.Body.String())
+		})
+	}
+}
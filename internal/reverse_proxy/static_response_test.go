@@ -0,0 +1,62 @@
+package reverse_proxy
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMapRedirect(t *testing.T) {
+	// arrange
+	ctx := context.Background()
+	reverseProxy := createTestReverseProxy()
+
+	_, err := reverseProxy.MapRedirect(ctx, "legacy-users", CreateTestPathTemplateMatcher("/v1/users/{id}"), 0, http.StatusMovedPermanently, "/v2/accounts/{param.id}")
+	require.NoError(t, err)
+	_, err = reverseProxy.MapRedirect(ctx, "www", CreateTestHostMatcher("example.com"), 0, http.StatusPermanentRedirect, "https://www.{host}{request_uri}")
+	require.NoError(t, err)
+
+	legacyResponse := httptest.NewRecorder()
+	wwwResponse := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(legacyResponse, httptest.NewRequest(http.MethodGet, "http://localhost/v1/users/42", nil))
+	reverseProxy.router.ServeHTTP(wwwResponse, httptest.NewRequest(http.MethodGet, "http://example.com/pricing?plan=pro", nil))
+
+	// assert
+	assert.Equal(t, http.StatusMovedPermanently, legacyResponse.Code)
+	assert.Equal(t, "/v2/accounts/42", legacyResponse.Header().Get("Location"))
+	assert.Equal(t, http.StatusPermanentRedirect, wwwResponse.Code)
+	assert.Equal(t, "https://www.example.com/pricing?plan=pro", wwwResponse.Header().Get("Location"))
+}
+
+func TestMapRedirectShouldRejectInvalidStatus(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+
+	// act
+	_, err := reverseProxy.MapRedirect(context.Background(), "invalid", CreateTestPathPrefixMatcher("/"), 0, http.StatusOK, "/")
+
+	// assert
+	assert.Error(t, err)
+	assert.Empty(t, reverseProxy.Routes())
+}
+
+func TestMapDirectResponse(t *testing.T) {
+	// arrange
+	reverseProxy := createTestReverseProxy()
+	robots := reverseProxy.MapDirectResponse(context.Background(), "robots", CreateTestPathPrefixMatcher("/robots.txt"), 0, http.StatusOK, "User-agent: *\nDisallow: /\n", nil)
+	response := httptest.NewRecorder()
+
+	// act
+	reverseProxy.router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://localhost/robots.txt", nil))
+
+	// assert
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", response.Body.String())
+	assert.NotEmpty(t, response.Header().Get("X-Request-Id"))
+	assert.Error(t, robots.UnregisterService(context.Background(), "localhost"))
+}
@@ -0,0 +1,59 @@
+package reverse_proxy
+
+import (
+	"context"
+	"github.com/noelmugnier/goprx/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMapSplitApplication(t *testing.T) {
+	// arrange
+	logger := slog.Default()
+	ctx := context.Background()
+	reverseProxy := createTestReverseProxy()
+
+	split := core.CreateTrafficSplit(&core.TrafficSplitStickiness{CookieName: "version"}, logger)
+	for _, version := range []string{"v1", "v2"} {
+		sbCfg := core.CreateRoundRobinServiceBalancerConfig(core.CreateDefaultHealthCheckConfig(1), 1000, 1)
+		sb := core.CreateServiceBalancer(core.CreateHttpRequestForwarderFactory(logger), sbCfg, logger)
+		sb.RegisterService(ctx, createTestService(handlerWritingVersion(version)))
+		require.NoError(t, split.AddBackend(version, sb, 50))
+	}
+
+	reverseProxy.MapSplitApplication(ctx, "api", CreateTestPathPrefixMatcher("/"), 0, split)
+
+	// act
+	firstResponse := httptest.NewRecorder()
+	reverseProxy.router.ServeHTTP(firstResponse, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	stickyCookie := firstResponse.Result().Cookies()
+	stickyVersions := make(map[string]int)
+	for range 10 {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		for _, cookie := range stickyCookie {
+			request.AddCookie(cookie)
+		}
+
+		response := httptest.NewRecorder()
+		reverseProxy.router.ServeHTTP(response, request)
+		stickyVersions[response.Body.String()]++
+	}
+
+	// assert
+	require.Equal(t, http.StatusOK, firstResponse.Code)
+	require.Len(t, stickyCookie, 1)
+	assert.Equal(t, "version", stickyCookie[0].Name)
+	assert.Equal(t, map[string]int{firstResponse.Body.String(): 10}, stickyVersions)
+}
+
+func handlerWritingVersion(version string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(version))
+	}
+}